@@ -0,0 +1,274 @@
+// Code generated by apigen from the OpenAPI document of the Journal API. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// BasePath is where the API version of the client is served
+const BasePath = "/api/v1"
+
+type Component struct {
+	ComponentID  int64      `json:"component_id"`
+	AssignmentID int64      `json:"assignment_id"`
+	Name         string     `json:"name"`
+	Weight       float64    `json:"weight"`
+	MaxScore     float64    `json:"max_score"`
+	DueDate      *time.Time `json:"due_date,omitempty"`
+	Score        *float64   `json:"score,omitempty"`
+}
+
+type Course struct {
+	CourseID       int64        `json:"course_id"`
+	CourseName     string       `json:"course_name"`
+	CourseNumber   int          `json:"course_number"`
+	AcademicYearID int64        `json:"academic_year_id,omitempty"`
+	UnitID         int64        `json:"unit_id,omitempty"`
+	AverageGrade   float64      `json:"average_grade,omitempty"`
+	Disciplines    []Discipline `json:"disciplines"`
+}
+
+type CourseCreation struct {
+	Name           string    `json:"name"`
+	Number         int       `json:"number"`
+	AcademicYearID int64     `json:"academic_year_id,omitempty"`
+	UnitID         int64     `json:"unit_id,omitempty"`
+	Subjects       []Subject `json:"subjects,omitempty"`
+}
+
+type CreateCourseResponse struct {
+	CourseID int64  `json:"course_id"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Discipline struct {
+	DisciplineID   int64       `json:"discipline_id"`
+	DisciplineName string      `json:"discipline_name"`
+	Teachers       []User      `json:"teachers,omitempty"`
+	Grade          Grade       `json:"grade,omitempty"`
+	Components     []Component `json:"components,omitempty"`
+	Total          *float64    `json:"total,omitempty"`
+}
+
+type EnrollStudentsResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Enrollment struct {
+	EnrollID  int64 `json:"enroll_id"`
+	CourseID  int64 `json:"course_id"`
+	StudentID int64 `json:"student_id"`
+}
+
+type Enrollments struct {
+	Enrolls []Enrollment `json:"enrolls,omitempty"`
+}
+
+type ExamConflict struct {
+	Kind         string     `json:"kind"`
+	AssignmentID int64      `json:"assignment_id,omitempty"`
+	ExamDate     *time.Time `json:"exam_date,omitempty"`
+	Message      string     `json:"message"`
+}
+
+type ExamGradeRequest struct {
+	AssignmentID int64     `json:"assignment_id"`
+	CourseID     int64     `json:"course_id"`
+	DisciplineID int64     `json:"discipline_id"`
+	TeacherID    int64     `json:"teacher_id"`
+	SemesterID   int64     `json:"semester_id,omitempty"`
+	ScaleID      int64     `json:"scale_id,omitempty"`
+	StudentID    int64     `json:"student_id"`
+	Grade        int       `json:"grade"`
+	GradeDate    time.Time `json:"grade_date"`
+}
+
+type ExamGradeResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ExamRules struct {
+	MinGapDays      int `json:"min_gap_days"`
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+type ExamSignUpRequest struct {
+	AssignmentID int64     `json:"assignment_id"`
+	CourseID     int64     `json:"course_id"`
+	DisciplineID int64     `json:"discipline_id"`
+	TeacherID    int64     `json:"teacher_id"`
+	SemesterID   int64     `json:"semester_id,omitempty"`
+	ScaleID      int64     `json:"scale_id,omitempty"`
+	ExamDate     time.Time `json:"exam_date"`
+}
+
+type ExamSignUpResponse struct {
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Conflicts []ExamConflict `json:"conflicts,omitempty"`
+}
+
+type GetCoursesResponse struct {
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+	Courses []Course `json:"courses"`
+}
+
+type GetRulesResponse struct {
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	MinGapDays      int    `json:"min_gap_days"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+type Grade struct {
+	GradeID   int64     `json:"grade_id"`
+	ExamID    int64     `json:"exam_id"`
+	TeacherID int64     `json:"teacher_id"`
+	Grade     int64     `json:"grade"`
+	ScaleID   int64     `json:"scale_id"`
+	Passed    bool      `json:"passed"`
+	GradeDate time.Time `json:"grade_date"`
+}
+
+type RemoveStudentsResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type SetRulesResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type SetScaleRequest struct {
+	ScaleID int64 `json:"scale_id"`
+}
+
+type SetScaleResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Subject struct {
+	TeacherID    int64 `json:"teacher_id"`
+	DisciplineID int64 `json:"discipline_id"`
+	SemesterID   int64 `json:"semester_id,omitempty"`
+	ScaleID      int64 `json:"scale_id,omitempty"`
+}
+
+type User struct {
+	UserID     int64  `json:"user_id"`
+	LastName   string `json:"last_name"`
+	FirstName  string `json:"first_name"`
+	Patronymic string `json:"patronymic"`
+}
+
+// CreateCourse calls POST /courses/create
+//
+// Create a course with its subjects
+func (c *Client) CreateCourse(ctx context.Context, body CourseCreation) (*CreateCourseResponse, error) {
+	var out CreateCourseResponse
+	err := c.do(ctx, http.MethodPost, "/courses/create", nil, body, &out)
+	return &out, err
+}
+
+// EnrollStudents calls POST /courses/{courseID}/modify/students
+//
+// Enroll students in the course
+func (c *Client) EnrollStudents(ctx context.Context, courseID int64, body Enrollments) (*EnrollStudentsResponse, error) {
+	var out EnrollStudentsResponse
+	err := c.do(ctx, http.MethodPost, "/courses/"+fmt.Sprint(courseID)+"/modify/students", nil, body, &out)
+	return &out, err
+}
+
+// ExamGrade calls POST /exams/grade
+//
+// Grade the exam of a student on the scale of the assignment
+func (c *Client) ExamGrade(ctx context.Context, body ExamGradeRequest) (*ExamGradeResponse, error) {
+	var out ExamGradeResponse
+	err := c.do(ctx, http.MethodPost, "/exams/grade", nil, body, &out)
+	return &out, err
+}
+
+// ExamSignUp calls POST /exams/signup
+//
+// Sign the student up for an exam
+func (c *Client) ExamSignUp(ctx context.Context, body ExamSignUpRequest) (*ExamSignUpResponse, error) {
+	var out ExamSignUpResponse
+	err := c.do(ctx, http.MethodPost, "/exams/signup", nil, body, &out)
+	return &out, err
+}
+
+// GetCoursesParams are the query parameters of GetCourses, nil fields are not sent
+type GetCoursesParams struct {
+	// Only the given academic year
+	AcademicYearID *int64
+	// Only the given semester
+	SemesterID *int64
+	// all disables the filtering, without any filter the current academic year is used
+	Period *string
+}
+
+// GetCourses calls GET /courses
+//
+// Courses of the user with disciplines, teachers, components and grades
+func (c *Client) GetCourses(ctx context.Context, params GetCoursesParams) (*GetCoursesResponse, error) {
+	query := url.Values{}
+	if params.AcademicYearID != nil {
+		query.Set("academic_year_id", fmt.Sprint(*params.AcademicYearID))
+	}
+	if params.SemesterID != nil {
+		query.Set("semester_id", fmt.Sprint(*params.SemesterID))
+	}
+	if params.Period != nil {
+		query.Set("period", fmt.Sprint(*params.Period))
+	}
+	var out GetCoursesResponse
+	err := c.do(ctx, http.MethodGet, "/courses", query, nil, &out)
+	return &out, err
+}
+
+// GetExamRules calls GET /exams/rules
+//
+// Rules of the exam scheduling
+func (c *Client) GetExamRules(ctx context.Context) (*GetRulesResponse, error) {
+	var out GetRulesResponse
+	err := c.do(ctx, http.MethodGet, "/exams/rules", nil, nil, &out)
+	return &out, err
+}
+
+// RemoveStudents calls DELETE /courses/{courseID}/modify/students
+//
+// Remove students from the course
+func (c *Client) RemoveStudents(ctx context.Context, courseID int64, body Enrollments) (*RemoveStudentsResponse, error) {
+	var out RemoveStudentsResponse
+	err := c.do(ctx, http.MethodDelete, "/courses/"+fmt.Sprint(courseID)+"/modify/students", nil, body, &out)
+	return &out, err
+}
+
+// SetAssignmentScale calls POST /assignments/{assignmentID}/scale
+//
+// Change the grading scale of an assignment
+func (c *Client) SetAssignmentScale(ctx context.Context, assignmentID int64, body SetScaleRequest) (*SetScaleResponse, error) {
+	var out SetScaleResponse
+	err := c.do(ctx, http.MethodPost, "/assignments/"+fmt.Sprint(assignmentID)+"/scale", nil, body, &out)
+	return &out, err
+}
+
+// SetExamRules calls POST /exams/rules/update
+//
+// Change the rules of the exam scheduling
+func (c *Client) SetExamRules(ctx context.Context, body ExamRules) (*SetRulesResponse, error) {
+	var out SetRulesResponse
+	err := c.do(ctx, http.MethodPost, "/exams/rules/update", nil, body, &out)
+	return &out, err
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/arxonic/journal/internal/lib/openapi"
+)
+
+const refPrefix = "#/components/schemas/"
+
+// Parts of the JSON names written in capitals in Go names
+var initialisms = map[string]string{
+	"id":   "ID",
+	"ids":  "IDs",
+	"url":  "URL",
+	"ics":  "ICS",
+	"http": "HTTP",
+	"json": "JSON",
+	"ip":   "IP",
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generate writes the types of the component schemas and a Client method per operation
+func generate(doc *openapi.Document, pkg string) ([]byte, error) {
+	g := &generator{imports: map[string]bool{"context": true, "net/http": true}}
+
+	base := ""
+	if len(doc.Servers) > 0 {
+		base = doc.Servers[0].URL
+	}
+	g.printf("// BasePath is where the API version of the client is served\n")
+	g.printf("const BasePath = %q\n\n", base)
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		g.printf("type %s %s\n\n", name, g.goType(doc.Components.Schemas[name]))
+	}
+
+	ops := doc.Operations()
+	sort.Slice(ops, func(i, j int) bool {
+		return operation(doc, ops[i]).OperationID < operation(doc, ops[j]).OperationID
+	})
+	for _, op := range ops {
+		if err := g.method(op[0], op[1], operation(doc, op)); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by apigen from the OpenAPI document of the %s. DO NOT EDIT.\n\n", doc.Info.Title)
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", pkg)
+
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&src, "\t%q\n", imp)
+	}
+	src.WriteString(")\n\n")
+	src.Write(g.buf.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not compile: %w", err)
+	}
+	return out, nil
+}
+
+func operation(doc *openapi.Document, op [2]string) *openapi.Operation {
+	return doc.Paths[op[1]][strings.ToLower(op[0])]
+}
+
+func (g *generator) goType(s *openapi.Schema) string {
+	if s.Ref != "" {
+		return strings.TrimPrefix(s.Ref, refPrefix)
+	}
+
+	var t string
+	switch s.Type {
+	case "boolean":
+		t = "bool"
+	case "integer":
+		switch s.Format {
+		case "int64":
+			t = "int64"
+		case "int32":
+			t = "int32"
+		default:
+			t = "int"
+		}
+	case "number":
+		if s.Format == "float" {
+			t = "float32"
+		} else {
+			t = "float64"
+		}
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			t = "time.Time"
+		case "byte":
+			t = "[]byte"
+		default:
+			t = "string"
+		}
+	case "array":
+		return "[]" + g.goType(s.Items)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties)
+		}
+		return g.structType(s)
+	default:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+
+	if s.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *generator) structType(s *openapi.Schema) string {
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, prop := range s.Properties {
+		tag := prop.Name
+		if !required[prop.Name] {
+			tag += ",omitempty"
+		}
+		if prop.Schema.Description != "" {
+			fmt.Fprintf(&b, "\t// %s\n", prop.Schema.Description)
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", goName(prop.Name), g.goType(prop.Schema), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// method writes the Client method of the operation. Path parameters become
+// arguments, query parameters an optional Params struct and the JSON body the
+// last argument. Header parameters are set by the Client itself.
+func (g *generator) method(method, path string, op *openapi.Operation) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("%s %s has no operationId", method, path)
+	}
+	name := goName(op.OperationID)
+
+	args := []string{"ctx context.Context"}
+	var query []openapi.Parameter
+
+	expr := fmt.Sprintf("%q", path)
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			arg := goArg(p.Name)
+			args = append(args, arg+" "+g.goType(p.Schema))
+			g.imports["fmt"] = true
+			expr = strings.Replace(expr, "{"+p.Name+"}", `"+fmt.Sprint(`+arg+`)+"`, 1)
+		case "query":
+			query = append(query, p)
+		}
+	}
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, `""+`), `+""`)
+
+	if len(query) > 0 {
+		g.printf("// %sParams are the query parameters of %s, nil fields are not sent\n", name, name)
+		g.printf("type %sParams struct {\n", name)
+		for _, p := range query {
+			if p.Description != "" {
+				g.printf("\t// %s\n", p.Description)
+			}
+			s := *p.Schema
+			s.Nullable = true
+			g.printf("\t%s %s\n", goName(p.Name), g.goType(&s))
+		}
+		g.printf("}\n\n")
+		args = append(args, "params "+name+"Params")
+	}
+
+	body := "nil"
+	if op.RequestBody != nil {
+		media, ok := op.RequestBody.Content[openapi.ContentJSON]
+		if !ok {
+			return fmt.Errorf("%s %s has no JSON request body", method, path)
+		}
+		args = append(args, "body "+g.goType(media.Schema))
+		body = "body"
+	}
+
+	result := ""
+	if media, ok := op.Responses["200"].Content[openapi.ContentJSON]; ok {
+		result = g.goType(media.Schema)
+	}
+
+	g.printf("// %s calls %s %s\n", name, method, path)
+	if op.Summary != "" {
+		g.printf("//\n// %s\n", op.Summary)
+	}
+	if result != "" {
+		g.printf("func (c *Client) %s(%s) (*%s, error) {\n", name, strings.Join(args, ", "), result)
+	} else {
+		g.printf("func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+
+	queryArg := "nil"
+	if len(query) > 0 {
+		g.imports["net/url"] = true
+		g.imports["fmt"] = true
+		queryArg = "query"
+		g.printf("query := url.Values{}\n")
+		for _, p := range query {
+			field := goName(p.Name)
+			g.printf("if params.%s != nil {\nquery.Set(%q, fmt.Sprint(*params.%s))\n}\n", field, p.Name, field)
+		}
+	}
+
+	methodConst := "http.Method" + strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+	if result != "" {
+		g.printf("var out %s\n", result)
+		g.printf("err := c.do(ctx, %s, %s, %s, %s, &out)\n", methodConst, expr, queryArg, body)
+		g.printf("return &out, err\n}\n\n")
+	} else {
+		g.printf("return c.do(ctx, %s, %s, %s, %s, nil)\n}\n\n", methodConst, expr, queryArg, body)
+	}
+	return nil
+}
+
+// goName turns a JSON or parameter name into an exported Go name
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range splitName(name) {
+		if upper, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// goArg turns a path parameter name into an unexported Go name
+func goArg(name string) string {
+	parts := splitName(name)
+	first := strings.ToLower(parts[0])
+	return first + strings.TrimPrefix(goName(name), goName(parts[0]))
+}
+
+// splitName splits snake_case, kebab-case and camelCase names into words
+func splitName(name string) []string {
+	var parts []string
+	var cur []rune
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			if len(cur) > 0 {
+				parts = append(parts, string(cur))
+			}
+			cur = nil
+		case unicode.IsUpper(r) && i > 0 && len(cur) > 0 && !unicode.IsUpper(cur[len(cur)-1]):
+			parts = append(parts, string(cur))
+			cur = []rune{r}
+		default:
+			cur = append(cur, r)
+		}
+	}
+	if len(cur) > 0 {
+		parts = append(parts, string(cur))
+	}
+	return parts
+}
@@ -0,0 +1,85 @@
+// apigen generates the Go client of the journal API from its OpenAPI document.
+//
+//	go run ./cmd/apigen -out client/client.gen.go
+//	go run ./cmd/apigen -spec http://localhost:8082/openapi.json -out client/client.gen.go
+//
+// Without -spec the document is built from the handlers of this tree, the same
+// way the server builds the one it serves at /openapi.json.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/arxonic/journal/internal/http-server/apidoc"
+	"github.com/arxonic/journal/internal/lib/buildinfo"
+	"github.com/arxonic/journal/internal/lib/openapi"
+)
+
+func main() {
+	var spec, base, out, pkg string
+	flag.StringVar(&spec, "spec", "", "file or URL of the OpenAPI document, built from the handlers if empty")
+	flag.StringVar(&base, "base", "/api/v1", "path the API is served at when the document is built from the handlers")
+	flag.StringVar(&out, "out", "client.gen.go", "file to write the client to")
+	flag.StringVar(&pkg, "package", "client", "package of the client")
+	flag.Parse()
+
+	doc, err := load(spec, base)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apigen: failed to load document:", err)
+		os.Exit(1)
+	}
+
+	src, err := generate(doc, pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apigen: failed to generate client:", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "apigen: failed to write client:", err)
+		os.Exit(1)
+	}
+}
+
+// load reads the document, the client is generated from its JSON form only
+func load(spec, base string) (*openapi.Document, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case spec == "":
+		data, err = json.Marshal(apidoc.Build(buildinfo.Get().Version, base))
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		data, err = fetch(spec)
+	default:
+		data, err = os.ReadFile(spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
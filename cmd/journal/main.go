@@ -3,27 +3,64 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"log/slog"
+	"net"
 	"os"
+	"strconv"
 
 	"github.com/arxonic/journal/internal/config"
-	"github.com/arxonic/journal/internal/http-server/handlers/url/courses"
-	"github.com/arxonic/journal/internal/http-server/handlers/url/exams"
+	"github.com/arxonic/journal/internal/http-server/apidoc"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/calendar"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/health"
 	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/http-server/middleware/cors"
+	"github.com/arxonic/journal/internal/http-server/middleware/csrf"
+	"github.com/arxonic/journal/internal/http-server/middleware/instrument"
+	"github.com/arxonic/journal/internal/http-server/middleware/logger"
+	"github.com/arxonic/journal/internal/http-server/middleware/ratelimit"
+	"github.com/arxonic/journal/internal/http-server/middleware/recoverer"
+	"github.com/arxonic/journal/internal/http-server/versioning"
+	"github.com/arxonic/journal/internal/lib/buildinfo"
 	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/arxonic/journal/internal/lib/openapi"
+	"github.com/arxonic/journal/internal/lib/readiness"
+	"github.com/arxonic/journal/internal/lib/smtpsink"
+	"github.com/arxonic/journal/internal/lib/tracing"
+	"github.com/arxonic/journal/internal/services/audit"
+	"github.com/arxonic/journal/internal/services/bus"
+	"github.com/arxonic/journal/internal/services/notify"
 	"github.com/arxonic/journal/internal/services/policy"
+	limiter "github.com/arxonic/journal/internal/services/ratelimit"
+	hooks "github.com/arxonic/journal/internal/services/webhooks"
 	"github.com/arxonic/journal/internal/storage/sqlite"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
 	envLocal = "local"
 	envDev   = "dev"
 	envProd  = "prod"
+
+	// Live updates kept for resuming event streams
+	liveHistory = 1000
+
+	// Config key of the unversioned routes
+	legacyVersion = "legacy"
 )
 
+// The unversioned routes are deprecated since /api/v1 was introduced
+var legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
 func main() {
 	// init config
 	cfg := config.MustLoad()
@@ -33,6 +70,17 @@ func main() {
 	log.Info("starting journal", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
+	// Stop on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// init tracing
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, cfg.Tracing.Endpoint, cfg.Tracing.SampleRatio, buildinfo.Get().Version)
+	if err != nil {
+		log.Error("failed to init tracing", sl.Err(err))
+		os.Exit(1)
+	}
+
 	// init storage
 	storage, err := sqlite.New(cfg.StoragePath)
 	if err != nil {
@@ -41,51 +89,225 @@ func main() {
 	}
 	log.Info("storage init successfully")
 
-	// Init access list
-	accessControl := policy.New()
+	// Background workers, the storage is closed after they are done
+	var workers sync.WaitGroup
 
-	// Init router
-	router := chi.NewRouter()
+	// init notifications
+	if cfg.SMTP.Fake {
+		sink, err := smtpsink.Start(net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)), log)
+		if err != nil {
+			log.Error("failed to start smtp sink", sl.Err(err))
+			os.Exit(1)
+		}
+		defer sink.Close()
 
-	// Middleware
-	authMiddleware := auth.New(cfg.Secret, storage)
-	router.Use(authMiddleware.Auth)
+		log.Info("smtp sink started, emails are not delivered", slog.String("address", sink.Addr()))
+	}
+
+	notifier := notify.New(log, storage)
 
-	// Handlers
-	url := "/courses"
-	accessControl.Add(url, "admin", "teacher", "student")
-	router.Get(url, courses.Get(url, log, storage, accessControl))
+	sender := notify.NewSMTPSender(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+	dispatcher := notify.NewDispatcher(log, storage, sender, cfg.SMTP.PollInterval, cfg.SMTP.MaxAttempts)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(ctx)
+	}()
 
-	url = "/courses/create"
-	accessControl.Add(url, "admin")
-	router.Post(url, courses.Create(url, log, storage, accessControl))
+	// init webhooks
+	hookClient := &http.Client{Timeout: cfg.Webhooks.Timeout}
+	hookDispatcher := hooks.NewDispatcher(log, storage, hookClient, cfg.Webhooks.PollInterval, cfg.Webhooks.MaxAttempts)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		hookDispatcher.Run(ctx)
+	}()
 
-	url = "/courses/{courseID}/modify/students"
-	accessControl.Add(url, "admin")
-	router.Post(url, courses.EnrollStudents(url, log, storage, accessControl))
-	router.Delete(url, courses.RemoveStudents(url, log, storage, accessControl))
+	// init live updates
+	events := bus.New(liveHistory)
 
-	url = "/exams/signup"
-	accessControl.Add(url, "student")
-	router.Post(url, exams.ExamSignUp(url, log, storage, accessControl))
+	// init audit log
+	auditor := audit.New()
 
-	url = "/exams/grade"
-	accessControl.Add(url, "teacher")
-	router.Post(url, exams.ExamGrade(url, log, storage, accessControl))
+	// Not ready until the server listens
+	var ready readiness.State
+
+	// Init router
+	root, apiDoc := newRouter(cfg, log, storage, notifier, events, auditor, &ready)
+
+	// API description, every documented operation must be routed
+	if err := apidoc.Check(apiDoc, root); err != nil {
+		log.Error("api description is out of date", sl.Err(err))
+		os.Exit(1)
+	}
 
 	// Start server
-	log.Info("staring server", slog.String("address", cfg.Address))
+	log.Info("staring server", slog.String("address", cfg.HTTPServer.Address))
 
 	srv := &http.Server{
-		Addr:    cfg.Address,
-		Handler: router,
+		Addr:         cfg.HTTPServer.Address,
+		Handler:      root,
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	}
+	// Event streams never finish by themselves
+	srv.RegisterOnShutdown(events.Close)
+
+	// Metrics are scraped on their own port
+	metricsSrv := &http.Server{
+		Addr:         cfg.Metrics.Address,
+		Handler:      metrics.Handler(),
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+	}
+
+	// Bind both ports before reporting ready, the servers then only accept
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Error("failed to listen", slog.String("address", srv.Addr), sl.Err(err))
+		os.Exit(1)
+	}
+
+	metricsLn, err := net.Listen("tcp", metricsSrv.Addr)
+	if err != nil {
+		log.Error("failed to listen", slog.String("address", metricsSrv.Addr), sl.Err(err))
+		os.Exit(1)
+	}
+
+	failed := make(chan error, 2)
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+	go func() {
+		log.Info("serving metrics", slog.String("address", cfg.Metrics.Address))
+		if err := metricsSrv.Serve(metricsLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	ready.Set(true)
+
+	exitCode := 0
+
+	select {
+	case <-ctx.Done():
+		log.Info("stopping server")
+	case err := <-failed:
+		log.Error("failed to start server", sl.Err(err))
+		exitCode = 1
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server")
+	// Drain in-flight requests
+	ready.Set(false)
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain requests", sl.Err(err))
+		exitCode = 1
+	}
+	if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to stop metrics server", sl.Err(err))
 	}
 
-	log.Error("server stopped")
+	workers.Wait()
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+		exitCode = 1
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
+
+	log.Info("server stopped")
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// newRouter builds the routes of the server and the description of the API
+// they serve
+func newRouter(cfg *config.Config, log *slog.Logger, storage *sqlite.Storage, notifier *notify.Notifier, events *bus.Bus, auditor *audit.Recorder, ready *readiness.State) (chi.Router, *openapi.Document) {
+	// init rate limits
+	limits := limiter.NewMemoryStore()
+
+	// Middleware of the API, every version applies it after marking its
+	// deprecation so that the rejected requests are marked as well
+	var api chi.Middlewares
+	if !cfg.CSRF.Disabled {
+		api = append(api, csrf.New(log, auth.RoleCookie, cfg.CORS.AllowedOrigins, cfg.CSRF.SecureCookie))
+	}
+	authMiddleware := auth.New(cfg.Secret, storage)
+	api = append(api,
+		ratelimit.AuthFailures(log, limiter.New("auth_failures", limiter.Limit(cfg.RateLimit.AuthFailures), limits)),
+		authMiddleware.Auth,
+		ratelimit.PerUser(log,
+			limiter.New("user", limiter.Limit(cfg.RateLimit.User), limits),
+			limiter.New("write", limiter.Limit(cfg.RateLimit.Write), limits),
+		),
+		instrument.Handler,
+	)
+
+	// API versions, the unversioned routes of the clients released before
+	// /api/v1 are served by v1 until their sunset. Every version keeps its
+	// own access list, a later one may change the roles of a route.
+	v1 := &versioning.Version{
+		Name:   "v1",
+		Routes: []func(chi.Router){routesV1(log, storage, notifier, events, auditor, policy.New())},
+	}
+	v1.Deprecation = deprecation(cfg.API, v1.Name, nil)
+
+	versions := []*versioning.Version{v1}
+	if !cfg.API.LegacyDisabled {
+		legacy := &versioning.Version{
+			Routes:      []func(chi.Router){routesV1(log, storage, notifier, events, auditor, policy.New())},
+			Deprecation: deprecation(cfg.API, legacyVersion, v1),
+		}
+		if legacy.Since.IsZero() {
+			legacy.Since = legacyDeprecated
+		}
+		versions = append(versions, legacy)
+	}
+
+	apiDoc := apidoc.Build(buildinfo.Get().Version, v1.Path())
+
+	// Public routes, authenticated by other means than the JWT or not at all
+	root := chi.NewRouter()
+	root.Use(middleware.RequestID)
+	root.Use(instrument.Tracing)
+	root.Use(logger.New(log))
+	root.Use(recoverer.New(log))
+	root.Use(cors.New(cfg.CORS.AllowedOrigins, cfg.CORS.AllowedHeaders, cfg.CORS.AllowCredentials, cfg.CORS.MaxAge))
+	root.Use(instrument.Metrics)
+	root.Use(ratelimit.PerIP(log, limiter.New("ip", limiter.Limit(cfg.RateLimit.IP), limits)))
+	root.Get(calendar.FeedPrefix+"{token}.ics", calendar.Feed(log, storage))
+	root.Get("/healthz", health.Live())
+	root.Get("/readyz", health.Ready(log, ready, storage, sqlite.SchemaVersion))
+	root.Get("/version", health.Version())
+	root.Get("/openapi.json", apidoc.Spec(apiDoc))
+	root.Get("/docs", apidoc.UI("/openapi.json"))
+	versioning.Mount(root, api, versions...)
+
+	return root, apiDoc
+}
+
+// deprecation of the version as configured, successor is the version replacing it
+func deprecation(cfg config.API, name string, successor *versioning.Version) versioning.Deprecation {
+	d := cfg.Deprecations[name]
+
+	return versioning.Deprecation{
+		Since:     d.Since,
+		Sunset:    d.Sunset,
+		Successor: successor,
+	}
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -94,15 +316,15 @@ func setupLogger(env string) *slog.Logger {
 	switch env {
 	case envLocal:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			sl.NewTraceHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envDev:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			sl.NewTraceHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envProd:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			sl.NewTraceHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})),
 		)
 	}
 
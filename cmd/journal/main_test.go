@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/arxonic/journal/client"
+	"github.com/arxonic/journal/internal/config"
+	"github.com/arxonic/journal/internal/http-server/apidoc"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/http-server/versioning"
+	"github.com/arxonic/journal/internal/lib/readiness"
+	"github.com/arxonic/journal/internal/services/audit"
+	"github.com/arxonic/journal/internal/services/bus"
+	"github.com/arxonic/journal/internal/services/notify"
+	"github.com/arxonic/journal/internal/storage/sqlite"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+const testSecret = "passphrasewhichneedstobe32bytes!"
+
+// newTestServer serves the router on a fresh database migrated to the schema
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "journal.db")
+
+	m, err := migrate.New("file://../../migrations", "sqlite3://"+path+"?x-migrations-table=migrations")
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	m.Close()
+
+	storage, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{Secret: testSecret}
+
+	var ready readiness.State
+	root, apiDoc := newRouter(cfg, log, storage, notify.New(log, storage), bus.New(liveHistory), audit.New(), &ready)
+
+	if err := apidoc.Check(apiDoc, root); err != nil {
+		t.Fatalf("apidoc.Check: %v", err)
+	}
+
+	srv := httptest.NewServer(root)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testToken(t *testing.T, email string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"email": email}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+
+	// A browser-like client keeps the role and CSRF cookies
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %v", err)
+	}
+	c := client.New(srv.URL, testToken(t, "adm@gmail.com"))
+	c.HTTPClient = &http.Client{Jar: jar}
+
+	if _, err := c.GetExamRules(ctx); err != nil {
+		t.Fatalf("GetExamRules: %v", err)
+	}
+
+	want := client.ExamRules{MinGapDays: 3, DurationMinutes: 120}
+	if _, err := c.SetExamRules(ctx, want); err != nil {
+		t.Fatalf("SetExamRules with the role cookie: %v", err)
+	}
+
+	rules, err := c.GetExamRules(ctx)
+	if err != nil {
+		t.Fatalf("GetExamRules: %v", err)
+	}
+	if rules.MinGapDays != want.MinGapDays || rules.DurationMinutes != want.DurationMinutes {
+		t.Fatalf("rules %+v, want %+v", rules, want)
+	}
+
+	// Failures are reported with the status of the response
+	var apiErr *client.Error
+
+	_, err = client.New(srv.URL, "").GetExamRules(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetExamRules without a token: %v, want status 401", err)
+	}
+
+	_, err = c.SetExamRules(ctx, client.ExamRules{MinGapDays: -1, DurationMinutes: 120})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Message == "" {
+		t.Fatalf("SetExamRules with invalid rules: %v, want status Error", err)
+	}
+
+	// The enrollments belong to the course of the path
+	_, err = c.EnrollStudents(ctx, 1, client.Enrollments{Enrolls: []client.Enrollment{{CourseID: 2, StudentID: 3}}})
+	if !errors.As(err, &apiErr) || apiErr.Message != "enrollments must belong to the course" {
+		t.Fatalf("EnrollStudents into another course: %v, want it rejected", err)
+	}
+}
+
+func TestLegacyRejectionsDeprecated(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		role       string
+		wantCode   int
+		deprecated bool
+	}{
+		{name: "legacy no token", path: "/courses", wantCode: http.StatusUnauthorized, deprecated: true},
+		{name: "legacy denied role", path: "/courses", role: "teacher", wantCode: http.StatusForbidden, deprecated: true},
+		{name: "legacy", path: "/courses", role: "admin", wantCode: http.StatusOK, deprecated: true},
+		{name: "v1 no token", path: "/api/v1/courses", wantCode: http.StatusUnauthorized},
+		{name: "v1", path: "/api/v1/courses", role: "admin", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			if tt.role != "" {
+				req.Header.Set("Authorization", "Bearer "+testToken(t, "adm@gmail.com"))
+				req.Header.Set(auth.ActiveRoleHeader, tt.role)
+			}
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.path, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("GET %s: status %d, want %d", tt.path, resp.StatusCode, tt.wantCode)
+			}
+
+			deprecated := resp.Header.Get(versioning.HeaderDeprecation) != ""
+			if deprecated != tt.deprecated {
+				t.Fatalf("GET %s: deprecated %v, want %v", tt.path, deprecated, tt.deprecated)
+			}
+			if tt.deprecated {
+				want := `</api/v1` + tt.path + `>; rel="successor-version"`
+				if link := resp.Header.Get(versioning.HeaderLink); link != want {
+					t.Fatalf("GET %s: link %q, want %q", tt.path, link, want)
+				}
+			}
+		})
+	}
+}
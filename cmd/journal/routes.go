@@ -0,0 +1,272 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/arxonic/journal/internal/http-server/handlers/url/attendance"
+	auditlog "github.com/arxonic/journal/internal/http-server/handlers/url/audit"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/calendar"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/courses"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/curricula"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/exams"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/gradebook"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/notifications"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/periods"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/roles"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/scales"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/stream"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/timetable"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/units"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/webhooks"
+	"github.com/arxonic/journal/internal/services/audit"
+	"github.com/arxonic/journal/internal/services/bus"
+	"github.com/arxonic/journal/internal/services/notify"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/arxonic/journal/internal/storage/sqlite"
+	"github.com/go-chi/chi/v5"
+)
+
+// routesV1 registers the handlers of /api/v1. A later version reuses them and
+// registers its own handlers for the changed endpoints afterwards.
+func routesV1(log *slog.Logger, storage *sqlite.Storage, notifier *notify.Notifier, events *bus.Bus, auditor *audit.Recorder, accessControl *policy.AccessControl) func(chi.Router) {
+	return func(router chi.Router) {
+		url := "/courses"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, courses.Get(url, log, storage, accessControl))
+
+		url = "/timetable"
+		accessControl.Add(url, "student", "teacher")
+		router.Get(url, timetable.Get(url, log, storage, accessControl))
+
+		url = "/rooms"
+		accessControl.Add(url, "admin", "teacher")
+		router.Get(url, timetable.GetRooms(url, log, storage, accessControl))
+
+		url = "/rooms/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, timetable.CreateRoom(url, log, storage, accessControl))
+
+		url = "/lessons/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, timetable.CreateLesson(url, log, storage, accessControl))
+
+		url = "/lessons/{lessonID}"
+		accessControl.Add(url, "admin")
+		router.Delete(url, timetable.DeleteLesson(url, log, storage, accessControl))
+
+		url = "/courses/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, courses.Create(url, log, storage, auditor, accessControl))
+
+		url = "/courses/{courseID}/modify/students"
+		accessControl.Add(url, "admin")
+		router.Post(url, courses.EnrollStudents(url, log, storage, notifier, events, auditor, accessControl))
+		router.Delete(url, courses.RemoveStudents(url, log, storage, notifier, events, auditor, accessControl))
+
+		url = "/assignments/{assignmentID}/scale"
+		accessControl.Add(url, "admin")
+		router.Post(url, courses.SetScale(url, log, storage, auditor, accessControl))
+
+		url = "/assignments/{assignmentID}/gradebook"
+		accessControl.Add(url, "teacher")
+		router.Get(url, gradebook.Get(url, log, storage, accessControl))
+
+		url = "/assignments/{assignmentID}/components"
+		accessControl.Add(url, "teacher")
+		router.Post(url, gradebook.CreateComponent(url, log, storage, accessControl))
+
+		url = "/assignments/{assignmentID}/scores"
+		accessControl.Add(url, "teacher")
+		router.Post(url, gradebook.SaveScores(url, log, storage, accessControl))
+
+		url = "/assignments/{assignmentID}/formula"
+		accessControl.Add(url, "teacher")
+		router.Post(url, gradebook.SetFormula(url, log, storage, accessControl))
+
+		url = "/assignments/{assignmentID}/sessions"
+		accessControl.Add(url, "teacher")
+		router.Get(url, attendance.GetSessions(url, log, storage, accessControl))
+		router.Post(url, attendance.CreateSession(url, log, storage, accessControl))
+
+		url = "/sessions/{sessionID}/attendance"
+		accessControl.Add(url, "teacher")
+		router.Post(url, attendance.Mark(url, log, storage, accessControl))
+
+		url = "/attendance"
+		accessControl.Add(url, "student")
+		router.Get(url, attendance.StudentReport(url, log, storage, accessControl))
+
+		url = "/students/{studentID}/attendance"
+		accessControl.Add(url, "admin", "teacher")
+		router.Get(url, attendance.StudentReport(url, log, storage, accessControl))
+
+		url = "/courses/{courseID}/attendance"
+		accessControl.Add(url, "admin", "teacher")
+		router.Get(url, attendance.CourseReport(url, log, storage, accessControl))
+
+		url = "/attendance/alerts"
+		accessControl.Add(url, "admin")
+		router.Get(url, attendance.Alerts(url, log, storage, accessControl))
+
+		url = "/attendance/threshold"
+		accessControl.Add(url, "admin")
+		router.Post(url, attendance.SetThreshold(url, log, storage, accessControl))
+
+		url = "/absences"
+		accessControl.Add(url, "student", "admin")
+		router.Get(url, attendance.GetDocuments(url, log, storage, accessControl))
+
+		url = "/absences/upload"
+		accessControl.Add(url, "student")
+		router.Post(url, attendance.UploadDocument(url, log, storage, accessControl))
+
+		url = "/absences/{documentID}/file"
+		accessControl.Add(url, "student", "admin")
+		router.Get(url, attendance.GetDocumentFile(url, log, storage, accessControl))
+
+		url = "/absences/{documentID}/review"
+		accessControl.Add(url, "admin")
+		router.Post(url, attendance.ReviewDocument(url, log, storage, accessControl))
+
+		url = "/exams/signup"
+		accessControl.Add(url, "student")
+		router.Post(url, exams.ExamSignUp(url, log, storage, notifier, auditor, accessControl))
+
+		url = "/exams/grade"
+		accessControl.Add(url, "teacher")
+		router.Post(url, exams.ExamGrade(url, log, storage, notifier, events, auditor, accessControl))
+
+		url = "/exams/rules"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, exams.GetRules(url, log, storage, accessControl))
+
+		url = "/exams/rules/update"
+		accessControl.Add(url, "admin")
+		router.Post(url, exams.SetRules(url, log, storage, auditor, accessControl))
+
+		url = "/periods"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, periods.Get(url, log, storage, accessControl))
+
+		url = "/periods/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, periods.Create(url, log, storage, accessControl))
+
+		url = "/periods/current"
+		accessControl.Add(url, "admin")
+		router.Post(url, periods.SetCurrent(url, log, storage, accessControl))
+
+		url = "/periods/{yearID}/archive"
+		accessControl.Add(url, "admin")
+		router.Post(url, periods.Archive(url, true, log, storage, accessControl))
+		router.Delete(url, periods.Archive(url, false, log, storage, accessControl))
+
+		url = "/periods/{yearID}/events"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, periods.GetEvents(url, log, storage, accessControl))
+
+		url = "/periods/{yearID}/events/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, periods.CreateEvent(url, log, storage, events, accessControl))
+
+		url = "/events/{eventID}"
+		accessControl.Add(url, "admin")
+		router.Delete(url, periods.DeleteEvent(url, log, storage, accessControl))
+
+		url = "/units"
+		accessControl.Add(url, "admin")
+		router.Get(url, units.Get(url, log, storage, accessControl))
+
+		url = "/units/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, units.Create(url, log, storage, accessControl))
+
+		url = "/units/{unitID}/members"
+		accessControl.Add(url, "admin")
+		router.Post(url, units.SaveMembers(url, log, storage, accessControl))
+
+		url = "/users/{userID}/roles"
+		accessControl.Add(url, "admin")
+		router.Get(url, roles.Get(url, log, storage, accessControl))
+		router.Post(url, roles.Add(url, log, storage, accessControl))
+		router.Delete(url, roles.Remove(url, log, storage, accessControl))
+
+		url = "/scales"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, scales.Get(url, log, storage, accessControl))
+
+		url = "/scales/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, scales.Create(url, log, storage, accessControl))
+
+		url = "/scales/conversions"
+		accessControl.Add(url, "admin")
+		router.Post(url, scales.SaveConversions(url, log, storage, accessControl))
+
+		url = "/programmes"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, curricula.GetProgrammes(url, log, storage, accessControl))
+
+		url = "/programmes/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, curricula.CreateProgramme(url, log, storage, accessControl))
+
+		url = "/curricula/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, curricula.Create(url, log, storage, accessControl))
+
+		url = "/curricula/{curriculumID}"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, curricula.Get(url, log, storage, accessControl))
+
+		url = "/curricula/{curriculumID}/students"
+		accessControl.Add(url, "admin")
+		router.Post(url, curricula.SaveStudents(url, log, storage, accessControl))
+
+		url = "/progress"
+		accessControl.Add(url, "student")
+		router.Get(url, curricula.Progress(url, log, storage, accessControl))
+
+		url = "/students/{studentID}/progress"
+		accessControl.Add(url, "admin", "teacher")
+		router.Get(url, curricula.Progress(url, log, storage, accessControl))
+
+		url = "/calendar/token"
+		accessControl.Add(url, "student", "teacher")
+		router.Post(url, calendar.IssueToken(url, log, storage, accessControl))
+		router.Delete(url, calendar.RevokeToken(url, log, storage, accessControl))
+
+		url = "/notifications/preferences"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, notifications.GetPreferences(url, log, storage, accessControl))
+		router.Post(url, notifications.SetPreferences(url, log, storage, accessControl))
+
+		url = "/webhooks"
+		accessControl.Add(url, "admin")
+		router.Get(url, webhooks.Get(url, log, storage, accessControl))
+
+		url = "/webhooks/create"
+		accessControl.Add(url, "admin")
+		router.Post(url, webhooks.Create(url, log, storage, accessControl))
+
+		url = "/webhooks/{webhookID}"
+		accessControl.Add(url, "admin")
+		router.Delete(url, webhooks.Delete(url, log, storage, accessControl))
+
+		url = "/webhooks/{webhookID}/deliveries"
+		accessControl.Add(url, "admin")
+		router.Get(url, webhooks.Deliveries(url, log, storage, accessControl))
+
+		url = "/stream"
+		accessControl.Add(url, "admin", "teacher", "student")
+		router.Get(url, stream.Get(url, log, events, accessControl))
+
+		url = "/audit"
+		accessControl.Add(url, "admin")
+		router.Get(url, auditlog.Search(url, log, storage, accessControl))
+
+		url = "/audit/verify"
+		accessControl.Add(url, "admin")
+		router.Get(url, auditlog.Verify(url, log, storage, accessControl))
+	}
+}
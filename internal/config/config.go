@@ -13,12 +13,101 @@ type Config struct {
 	StoragePath string `yaml:"storage_path" env-required:"true"`
 	Secret      string `yaml:"secret" env-required:"true"`
 	HTTPServer  `yaml:"http_server"`
+	SMTP        `yaml:"smtp"`
+	Webhooks    `yaml:"webhooks"`
+	Metrics     `yaml:"metrics"`
+	Tracing     `yaml:"tracing"`
+	RateLimit   `yaml:"rate_limit"`
+	CORS        `yaml:"cors"`
+	CSRF        `yaml:"csrf"`
+	API         `yaml:"api"`
 }
 
+// HTTPServer is the API server. Timeout bounds reading a request and writing
+// its response; on shutdown in-flight requests get ShutdownTimeout to finish.
 type HTTPServer struct {
-	Address     string        `yaml:"address" envDefault:"localhost:9999"`
-	Timeout     time.Duration `yaml:"timeout" envDefault:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" envDefault:"60s"`
+	Address         string        `yaml:"address" env-default:"localhost:9999"`
+	Timeout         time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+}
+
+// SMTP is the mail server notifications are sent through. Fake starts the
+// built-in sink on Host:Port which logs emails instead of delivering them.
+type SMTP struct {
+	Host         string        `yaml:"host" env-default:"localhost"`
+	Port         int           `yaml:"port" env-default:"2525"`
+	Username     string        `yaml:"username" env:"SMTP_USERNAME"`
+	Password     string        `yaml:"password" env:"SMTP_PASSWORD"`
+	From         string        `yaml:"from" env-default:"journal@localhost"`
+	Fake         bool          `yaml:"fake"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"8"`
+}
+
+// Webhooks is the delivery of domain events to the webhook subscriptions
+type Webhooks struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"10"`
+}
+
+// Metrics is the listener of the Prometheus endpoint, kept apart from the API
+type Metrics struct {
+	Address string `yaml:"address" env-default:"localhost:9100"`
+}
+
+// Tracing is the export of OpenTelemetry spans. Exporter is "none", "stdout"
+// or "otlp", the latter sends OTLP over HTTP to the collector at Endpoint.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// RateLimit holds token buckets of rate requests per second up to burst at
+// once. IP applies to every client address and AuthFailures to its failed
+// authentications, User to every user and Write to the writes of a user.
+// A zero rate turns the limit off.
+type RateLimit struct {
+	IP           Limit `yaml:"ip"`
+	AuthFailures Limit `yaml:"auth_failures"`
+	User         Limit `yaml:"user"`
+	Write        Limit `yaml:"write"`
+}
+
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// CORS lets the browser apps served from AllowedOrigins call the API.
+// AllowedHeaders defaults to the request headers the API reads.
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" env-default:"10m"`
+}
+
+// CSRF guards the state-changing requests of browser sessions, the CORS
+// origins are trusted. SecureCookie should be on behind HTTPS.
+type CSRF struct {
+	Disabled     bool `yaml:"disabled"`
+	SecureCookie bool `yaml:"secure_cookie"`
+}
+
+// API is the versioning of the routes. Deprecations are keyed by the version
+// name, "legacy" being the unversioned routes kept for the clients released
+// before /api/v1. LegacyDisabled stops serving them after their sunset.
+type API struct {
+	Deprecations   map[string]Deprecation `yaml:"deprecations"`
+	LegacyDisabled bool                   `yaml:"legacy_disabled"`
+}
+
+type Deprecation struct {
+	Since  time.Time `yaml:"since"`
+	Sunset time.Time `yaml:"sunset"`
 }
 
 func MustLoad() *Config {
@@ -3,5 +3,36 @@ package models
 type Key struct {
 	ID    int64
 	Email string
-	Role  string
+	// Never nil for keys loaded from storage, users without roles get an empty slice
+	Roles []Role
+	// Version of the roles the key was loaded with, see Storage.RolesVersion
+	RolesVersion int64
+	// Role selected by the client for the current request, empty if none
+	Active string `json:"-"`
+}
+
+type Role struct {
+	Name string
+	// Org unit the role is limited to, zero for the whole institute
+	Scope int64
+}
+
+func (k *Key) Has(role string) bool {
+	for _, r := range k.Roles {
+		if r.Name == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes returns the org units the role is held in, zero stands for the whole institute
+func (k *Key) Scopes(role string) []int64 {
+	var scopes []int64
+	for _, r := range k.Roles {
+		if r.Name == role {
+			scopes = append(scopes, r.Scope)
+		}
+	}
+	return scopes
 }
@@ -1,6 +1,9 @@
 package scheme
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // User
 type User struct {
@@ -10,16 +13,30 @@ type User struct {
 	Patronymic string `json:"patronymic"`
 }
 
+// Roles
+type UserRoles struct {
+	Roles []UserRole `json:"roles"`
+}
+
+type UserRole struct {
+	Role   string `json:"role"`
+	UnitID int64  `json:"unit_id,omitempty"`
+}
+
 // Test
 type CourseCreation struct {
-	Name     string    `json:"name"`
-	Number   int       `json:"number"`
-	Subjects []Subject `json:"subjects,omitempty"`
+	Name           string    `json:"name"`
+	Number         int       `json:"number"`
+	AcademicYearID int64     `json:"academic_year_id,omitempty"`
+	UnitID         int64     `json:"unit_id,omitempty"`
+	Subjects       []Subject `json:"subjects,omitempty"`
 }
 
 type Subject struct {
 	TeacherID    int64 `json:"teacher_id"`
 	DisciplineID int64 `json:"discipline_id"`
+	SemesterID   int64 `json:"semester_id,omitempty"`
+	ScaleID      int64 `json:"scale_id,omitempty"`
 }
 
 // Enrollments
@@ -39,9 +56,13 @@ type Courses struct {
 }
 
 type Course struct {
-	ID     int64  `json:"course_id"`
-	Name   string `json:"course_name"`
-	Number int    `json:"course_number"`
+	ID             int64  `json:"course_id"`
+	Name           string `json:"course_name"`
+	Number         int    `json:"course_number"`
+	AcademicYearID int64  `json:"academic_year_id,omitempty"`
+	UnitID         int64  `json:"unit_id,omitempty"`
+	// Mean of the discipline grades converted to the five-point scale
+	Average float64 `json:"average_grade,omitempty"`
 	Disciplines
 }
 
@@ -55,6 +76,10 @@ type Discipline struct {
 	Name     string `json:"discipline_name"`
 	Teachers []User `json:"teachers,omitempty"`
 	Grade    Grade  `json:"grade,omitempty"`
+	// Continuous assessment of the current user
+	Components []Component `json:"components,omitempty"`
+	// Total of the components in percent, 0-100, students only
+	Total *float64 `json:"total,omitempty"`
 }
 
 // Assignments
@@ -67,6 +92,8 @@ type Assignment struct {
 	CourseID     int64 `json:"course_id"`
 	DisciplineID int64 `json:"discipline_id"`
 	TeacherID    int64 `json:"teacher_id"`
+	SemesterID   int64 `json:"semester_id,omitempty"`
+	ScaleID      int64 `json:"scale_id,omitempty"`
 }
 
 // Exam
@@ -83,5 +110,614 @@ type Grade struct {
 	ExamID    int64     `json:"exam_id"`
 	TeacherID int64     `json:"teacher_id"`
 	Grade     int64     `json:"grade"`
+	ScaleID   int64     `json:"scale_id"`
+	Passed    bool      `json:"passed"`
 	GradeDate time.Time `json:"grade_date"`
 }
+
+// Grading scales
+const (
+	ScaleFivePoint = "five_point"
+	ScaleRating100 = "rating_100"
+)
+
+type Scales struct {
+	Scales      []Scale      `json:"scales"`
+	Conversions []Conversion `json:"conversions"`
+}
+
+type Scale struct {
+	ID   int64  `json:"scale_id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Min  int64  `json:"min_value"`
+	Max  int64  `json:"max_value"`
+	Pass int64  `json:"pass_value"`
+}
+
+// Conversion maps values FromMin..FromMax of one scale to ToValue of another
+type Conversion struct {
+	FromScaleID int64 `json:"from_scale_id"`
+	ToScaleID   int64 `json:"to_scale_id"`
+	FromMin     int64 `json:"from_min"`
+	FromMax     int64 `json:"from_max"`
+	ToValue     int64 `json:"to_value"`
+}
+
+type Conversions struct {
+	Conversions []Conversion `json:"conversions"`
+}
+
+// Periods
+type AcademicYears struct {
+	AcademicYears     []AcademicYear `json:"academic_years"`
+	CurrentSemesterID int64          `json:"current_semester_id,omitempty"`
+}
+
+type AcademicYear struct {
+	ID        int64      `json:"academic_year_id"`
+	Name      string     `json:"name"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   time.Time  `json:"end_date"`
+	Archived  bool       `json:"archived"`
+	Semesters []Semester `json:"semesters,omitempty"`
+}
+
+type Semester struct {
+	ID             int64     `json:"semester_id"`
+	AcademicYearID int64     `json:"academic_year_id"`
+	Number         int       `json:"semester_number"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+}
+
+// Period narrows listings down to an academic year and/or a semester.
+// Zero fields are not filtered on.
+type Period struct {
+	AcademicYearID int64
+	SemesterID     int64
+}
+
+// Org units
+const (
+	UnitInstitute  = "institute"
+	UnitFaculty    = "faculty"
+	UnitDepartment = "department"
+)
+
+type Units struct {
+	Units []Unit `json:"units"`
+}
+
+type Unit struct {
+	ID       int64  `json:"unit_id"`
+	ParentID int64  `json:"parent_id,omitempty"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+type UnitMembers struct {
+	UserIDs       []int64 `json:"user_ids,omitempty"`
+	DisciplineIDs []int64 `json:"discipline_ids,omitempty"`
+	CourseIDs     []int64 `json:"course_ids,omitempty"`
+}
+
+// Curricula
+const (
+	ItemRequired = "required"
+	ItemElective = "elective"
+
+	AssessmentExam       = "exam"
+	AssessmentPassFail   = "pass_fail"
+	AssessmentCoursework = "coursework"
+)
+
+type Programmes struct {
+	Programmes []Programme `json:"programmes"`
+}
+
+type Programme struct {
+	ID     int64  `json:"programme_id"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	UnitID int64  `json:"unit_id,omitempty"`
+}
+
+type Curriculum struct {
+	ID              int64            `json:"curriculum_id"`
+	ProgrammeID     int64            `json:"programme_id"`
+	Name            string           `json:"name"`
+	AdmissionYear   int              `json:"admission_year"`
+	ElectiveCredits int              `json:"elective_credits"`
+	Items           []CurriculumItem `json:"items"`
+}
+
+type CurriculumItem struct {
+	DisciplineID int64  `json:"discipline_id"`
+	Semester     int    `json:"semester"`
+	Kind         string `json:"kind"`
+	CreditHours  int    `json:"credit_hours"`
+	Assessment   string `json:"assessment"`
+}
+
+type CurriculumStudents struct {
+	StudentIDs []int64 `json:"student_ids"`
+}
+
+// Progress
+type Progress struct {
+	StudentID             int64          `json:"student_id"`
+	CurriculumID          int64          `json:"curriculum_id"`
+	RequiredCredits       int            `json:"required_credits"`
+	EarnedRequiredCredits int            `json:"earned_required_credits"`
+	ElectiveCredits       int            `json:"elective_credits"`
+	EarnedElectiveCredits int            `json:"earned_elective_credits"`
+	Completed             bool           `json:"completed"`
+	Items                 []ItemProgress `json:"items"`
+}
+
+type ItemProgress struct {
+	CurriculumItem
+	Grade  *Grade `json:"grade,omitempty"`
+	Passed bool   `json:"passed"`
+}
+
+// Gradebook
+const (
+	FormulaWeightedMean = "weighted_mean"
+	FormulaWeightedSum  = "weighted_sum"
+)
+
+type Components struct {
+	Components []Component `json:"components"`
+}
+
+type Component struct {
+	ID           int64      `json:"component_id"`
+	AssignmentID int64      `json:"assignment_id"`
+	Name         string     `json:"name"`
+	Weight       float64    `json:"weight"`
+	MaxScore     float64    `json:"max_score"`
+	DueDate      *time.Time `json:"due_date,omitempty"`
+	// Score of the student, nil if not scored yet
+	Score *float64 `json:"score,omitempty"`
+}
+
+type Scores struct {
+	Scores []Score `json:"scores"`
+}
+
+type Score struct {
+	ComponentID int64   `json:"component_id"`
+	StudentID   int64   `json:"student_id"`
+	Score       float64 `json:"score"`
+}
+
+type Gradebook struct {
+	AssignmentID int64              `json:"assignment_id"`
+	Formula      string             `json:"final_formula"`
+	Components   []Component        `json:"components"`
+	Students     []StudentGradebook `json:"students"`
+}
+
+type StudentGradebook struct {
+	StudentID int64   `json:"student_id"`
+	Scores    []Score `json:"scores"`
+	// Total in percent, 0-100
+	Total float64 `json:"total"`
+	// Final grade on the assignment scale the total converts to
+	SuggestedGrade *int64 `json:"suggested_grade,omitempty"`
+}
+
+// Attendance
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceExcused = "excused"
+	AttendanceLate    = "late"
+)
+
+const (
+	DocumentPending  = "pending"
+	DocumentApproved = "approved"
+	DocumentRejected = "rejected"
+)
+
+type ClassSessions struct {
+	Sessions []ClassSession `json:"sessions"`
+}
+
+type ClassSession struct {
+	ID           int64     `json:"session_id"`
+	AssignmentID int64     `json:"assignment_id"`
+	SessionDate  time.Time `json:"session_date"`
+	Topic        string    `json:"topic,omitempty"`
+}
+
+type AttendanceMarks struct {
+	Marks []AttendanceMark `json:"marks"`
+}
+
+type AttendanceMark struct {
+	StudentID int64  `json:"student_id"`
+	Status    string `json:"status"`
+}
+
+type AbsenceDocuments struct {
+	Documents []AbsenceDocument `json:"documents"`
+}
+
+type AbsenceDocument struct {
+	ID          int64     `json:"document_id"`
+	StudentID   int64     `json:"student_id"`
+	DateFrom    time.Time `json:"date_from"`
+	DateTo      time.Time `json:"date_to"`
+	Reason      string    `json:"reason,omitempty"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Content     []byte    `json:"-"`
+	Status      string    `json:"status"`
+	ReviewerID  int64     `json:"reviewer_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Filter of the attendance report, zero fields are not applied
+type AttendanceFilter struct {
+	StudentID int64
+	CourseID  int64
+	TeacherID int64
+	Period    Period
+}
+
+type AttendanceReport struct {
+	Stats []AttendanceStat `json:"attendance"`
+}
+
+// Attendance of a student in an assignment
+type AttendanceStat struct {
+	StudentID    int64 `json:"student_id"`
+	CourseID     int64 `json:"course_id"`
+	AssignmentID int64 `json:"assignment_id"`
+	DisciplineID int64 `json:"discipline_id"`
+	Present      int   `json:"present"`
+	Absent       int   `json:"absent"`
+	Excused      int   `json:"excused"`
+	Late         int   `json:"late"`
+	Total        int   `json:"total"`
+	// Share of unexcused absences in percent
+	AbsenceRate float64 `json:"absence_rate"`
+}
+
+type AttendanceAlerts struct {
+	Threshold float64           `json:"threshold"`
+	Alerts    []AttendanceAlert `json:"alerts"`
+}
+
+// Student whose unexcused absences in a course exceed the threshold
+type AttendanceAlert struct {
+	StudentID   int64   `json:"student_id"`
+	CourseID    int64   `json:"course_id"`
+	Absent      int     `json:"absent"`
+	Total       int     `json:"total"`
+	AbsenceRate float64 `json:"absence_rate"`
+}
+
+// Timetable
+const (
+	WeekEvery = "every"
+	WeekOdd   = "odd"
+	WeekEven  = "even"
+)
+
+const (
+	ConflictTeacher  = "teacher"
+	ConflictGroup    = "group"
+	ConflictRoom     = "room"
+	ConflictCapacity = "capacity"
+)
+
+type Rooms struct {
+	Rooms []Room `json:"rooms"`
+}
+
+type Room struct {
+	ID       int64  `json:"room_id"`
+	Name     string `json:"name"`
+	Building string `json:"building,omitempty"`
+	Capacity int    `json:"capacity"`
+}
+
+type Timetable struct {
+	Lessons []Lesson `json:"lessons"`
+}
+
+type Lesson struct {
+	ID           int64 `json:"lesson_id"`
+	AssignmentID int64 `json:"assignment_id"`
+	CourseID     int64 `json:"course_id"`
+	DisciplineID int64 `json:"discipline_id"`
+	TeacherID    int64 `json:"teacher_id"`
+	RoomID       int64 `json:"room_id"`
+	// Weekday 1-7, Monday is 1
+	Weekday int `json:"weekday"`
+	// Time of day as HH:MM
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	WeekParity string `json:"week_parity"`
+}
+
+type LessonConflict struct {
+	Kind     string `json:"kind"`
+	LessonID int64  `json:"lesson_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Calendar
+type CalendarToken struct {
+	Token   string `json:"token"`
+	FeedURL string `json:"feed_url"`
+}
+
+// Exam registration of a student or, for a teacher, an exam slot with the number of registered students
+type ExamEvent struct {
+	AssignmentID   int64     `json:"assignment_id"`
+	CourseName     string    `json:"course_name"`
+	DisciplineName string    `json:"discipline_name"`
+	ExamDate       time.Time `json:"exam_date"`
+	Students       int       `json:"students,omitempty"`
+}
+
+// Lesson with the names and the term it repeats within
+type LessonEvent struct {
+	Lesson
+	CourseName     string    `json:"course_name"`
+	DisciplineName string    `json:"discipline_name"`
+	RoomName       string    `json:"room_name"`
+	TermStart      time.Time `json:"term_start"`
+	TermEnd        time.Time `json:"term_end"`
+}
+
+// Exam scheduling
+const (
+	ConflictExamOverlap        = "overlap"
+	ConflictExamGap            = "gap"
+	ConflictHoliday            = "holiday"
+	ConflictOutsideSession     = "outside_session"
+	ConflictRegistrationClosed = "registration_closed"
+)
+
+type ExamRules struct {
+	// Minimum number of days between two exams of a student, 0 disables the check
+	MinGapDays int `json:"min_gap_days"`
+	// Length of a timed exam, exams without a time of day take the whole day
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+type ExamConflict struct {
+	Kind         string     `json:"kind"`
+	AssignmentID int64      `json:"assignment_id,omitempty"`
+	ExamDate     *time.Time `json:"exam_date,omitempty"`
+	Message      string     `json:"message"`
+}
+
+// Academic calendar
+const (
+	EventTerm         = "term"
+	EventExamSession  = "exam_session"
+	EventHoliday      = "holiday"
+	EventRegistration = "registration"
+	EventDeadline     = "deadline"
+)
+
+type AcademicEvents struct {
+	Events []AcademicEvent `json:"events"`
+}
+
+type AcademicEvent struct {
+	ID int64 `json:"event_id"`
+	// Zero for events not bound to an academic year
+	AcademicYearID int64     `json:"academic_year_id,omitempty"`
+	Kind           string    `json:"kind"`
+	Name           string    `json:"name"`
+	DateFrom       time.Time `json:"date_from"`
+	DateTo         time.Time `json:"date_to"`
+}
+
+// Notifications
+const (
+	NotifyExamSignUp = "exam_signup"
+	NotifyExamGraded = "exam_graded"
+	NotifyEnrolled   = "enrolled"
+	NotifyUnenrolled = "unenrolled"
+	// Sent to the students once an exam they signed up for is moved. Exam
+	// dates cannot be changed yet, the kind is kept for the opt-outs.
+	NotifyExamRescheduled = "exam_rescheduled"
+
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+type NotificationPreferences struct {
+	Language string `json:"language"`
+	// Kinds of notifications the user opted out of
+	Disabled []string `json:"disabled"`
+}
+
+// NotificationData is what a domain event tells about itself, the notifier
+// resolves the names for the templates
+type NotificationData struct {
+	StudentID    int64     `json:"student_id,omitempty"`
+	CourseID     int64     `json:"course_id,omitempty"`
+	DisciplineID int64     `json:"discipline_id,omitempty"`
+	ExamDate     time.Time `json:"exam_date,omitempty"`
+	Grade        int       `json:"grade,omitempty"`
+}
+
+// Notification is an email in the outbox
+type Notification struct {
+	ID            int64
+	UserID        int64
+	Kind          string
+	Email         string
+	Subject       string
+	Body          string
+	Attempts      int
+	NextAttemptAt time.Time
+}
+
+// Webhooks
+const (
+	TopicCourseCreated     = "course.created"
+	TopicStudentEnrolled   = "student.enrolled"
+	TopicStudentUnenrolled = "student.unenrolled"
+	TopicExamSignedUp      = "exam.signed_up"
+	TopicGradePosted       = "grade.posted"
+
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+type Webhooks struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type Webhook struct {
+	ID  int64  `json:"webhook_id"`
+	URL string `json:"url"`
+	// Key of the HMAC signature, only shown when the webhook is created
+	Secret string `json:"secret,omitempty"`
+	// Topics the webhook is subscribed to, every topic if empty
+	Topics    []string  `json:"topics"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DomainEvent is an event in the outbox, recorded in the transaction of the change
+type DomainEvent struct {
+	ID        int64           `json:"event_id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type WebhookDeliveries struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type WebhookDelivery struct {
+	ID            int64       `json:"delivery_id"`
+	WebhookID     int64       `json:"webhook_id"`
+	Event         DomainEvent `json:"event"`
+	Status        string      `json:"status"`
+	Attempts      int         `json:"attempts"`
+	ResponseCode  int         `json:"response_code,omitempty"`
+	LastError     string      `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time  `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time  `json:"delivered_at,omitempty"`
+}
+
+type CourseCreatedEvent struct {
+	CourseID       int64  `json:"course_id"`
+	Name           string `json:"course_name"`
+	Number         int    `json:"course_number"`
+	AcademicYearID int64  `json:"academic_year_id,omitempty"`
+	UnitID         int64  `json:"unit_id,omitempty"`
+}
+
+type EnrollmentEvent struct {
+	CourseID  int64 `json:"course_id"`
+	StudentID int64 `json:"student_id"`
+}
+
+type ExamSignedUpEvent struct {
+	ExamID       int64     `json:"exam_id"`
+	StudentID    int64     `json:"student_id"`
+	AssignmentID int64     `json:"assignment_id"`
+	ExamDate     time.Time `json:"exam_date"`
+}
+
+type GradePostedEvent struct {
+	ExamID       int64     `json:"exam_id"`
+	StudentID    int64     `json:"student_id"`
+	AssignmentID int64     `json:"assignment_id"`
+	TeacherID    int64     `json:"teacher_id"`
+	Grade        int       `json:"grade"`
+	GradeDate    time.Time `json:"grade_date"`
+}
+
+// PendingDelivery is a delivery due to be sent together with its webhook
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// Live updates
+const (
+	LiveGradePosted       = "grade.posted"
+	LiveEnrollmentChanged = "enrollment.changed"
+	LiveExamSlotOpened    = "exam.slot_opened"
+	// The client missed updates and has to reload its state
+	LiveResync = "resync"
+)
+
+type GradeUpdate struct {
+	CourseID     int64     `json:"course_id"`
+	DisciplineID int64     `json:"discipline_id"`
+	Grade        int       `json:"grade"`
+	ExamDate     time.Time `json:"exam_date"`
+}
+
+type EnrollmentUpdate struct {
+	CourseID int64 `json:"course_id"`
+	Enrolled bool  `json:"enrolled"`
+}
+
+// Audit log
+const (
+	AuditCourseCreate    = "course.create"
+	AuditStudentsEnroll  = "course.enroll_students"
+	AuditStudentsRemove  = "course.remove_students"
+	AuditScaleSet        = "assignment.set_scale"
+	AuditExamSignUp      = "exam.sign_up"
+	AuditExamGrade       = "exam.grade"
+	AuditExamRulesUpdate = "exam_rules.update"
+)
+
+type AuditEntries struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+type AuditEntry struct {
+	ID        int64           `json:"audit_id"`
+	At        time.Time       `json:"at"`
+	ActorID   int64           `json:"actor_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditFilter narrows the audit log search, zero fields are not applied
+type AuditFilter struct {
+	ActorID  int64
+	Action   string
+	Entity   string
+	EntityID int64
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log
+type AuditVerification struct {
+	Valid   bool `json:"valid"`
+	Checked int  `json:"checked"`
+	// First entry whose hash does not match, zero if the chain is intact
+	BrokenID int64 `json:"broken_id,omitempty"`
+}
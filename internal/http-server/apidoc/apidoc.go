@@ -0,0 +1,202 @@
+// Package apidoc publishes the OpenAPI description of the journal API. The
+// operations reference the request and response types of the handlers, and
+// Check verifies at startup that every documented operation is routed.
+package apidoc
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/courses"
+	"github.com/arxonic/journal/internal/http-server/handlers/url/exams"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/api/period"
+	"github.com/arxonic/journal/internal/lib/openapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	title       = "Journal API"
+	description = "Online directorate of the institute. Every response carries status OK or Error, " +
+		"failures are reported with status Error and a message in the error field."
+)
+
+// Role chosen for the request when the resource is open to several roles of the user
+var activeRole = openapi.Parameter{
+	Name:        auth.ActiveRoleHeader,
+	In:          "header",
+	Description: "Role of the request when the user has several roles allowed for the resource",
+	Schema:      &openapi.Schema{Type: "string", Enum: []string{"admin", "teacher", "student"}},
+}
+
+// Filters of the listing endpoints, see period.FromRequest
+var periodQuery = []openapi.Parameter{
+	{
+		Name:        period.ParamAcademicYear,
+		In:          "query",
+		Description: "Only the given academic year",
+		Schema:      &openapi.Schema{Type: "integer", Format: "int64"},
+	},
+	{
+		Name:        period.ParamSemester,
+		In:          "query",
+		Description: "Only the given semester",
+		Schema:      &openapi.Schema{Type: "integer", Format: "int64"},
+	},
+	{
+		Name:        period.ParamPeriod,
+		In:          "query",
+		Description: "all disables the filtering, without any filter the current academic year is used",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{period.PeriodAll}},
+	},
+}
+
+// Build describes the documented operations of the API served at base
+func Build(version, base string) *openapi.Document {
+	d := openapi.New(title, version, description)
+	d.Servers = []openapi.Server{{URL: base}}
+
+	// Courses
+	d.Add(http.MethodGet, "/courses", openapi.Op{
+		ID:       "GetCourses",
+		Tag:      "courses",
+		Summary:  "Courses of the user with disciplines, teachers, components and grades",
+		Query:    periodQuery,
+		Response: courses.GetCoursesResponse{},
+	})
+	d.Add(http.MethodPost, "/courses/create", openapi.Op{
+		ID:       "CreateCourse",
+		Tag:      "courses",
+		Summary:  "Create a course with its subjects",
+		Request:  scheme.CourseCreation{},
+		Response: courses.CreateCourseResponse{},
+	})
+	d.Add(http.MethodPost, "/courses/{courseID}/modify/students", openapi.Op{
+		ID:          "EnrollStudents",
+		Tag:         "courses",
+		Summary:     "Enroll students in the course",
+		Description: "The course_id of an enrollment may be left out, it must be the course of the path.",
+		Request:     scheme.Enrollments{},
+		Response:    courses.EnrollStudentsResponse{},
+	})
+	d.Add(http.MethodDelete, "/courses/{courseID}/modify/students", openapi.Op{
+		ID:          "RemoveStudents",
+		Tag:         "courses",
+		Summary:     "Remove students from the course",
+		Description: "The course_id of an enrollment may be left out, it must be the course of the path.",
+		Request:     scheme.Enrollments{},
+		Response:    courses.RemoveStudentsResponse{},
+	})
+	d.Add(http.MethodPost, "/assignments/{assignmentID}/scale", openapi.Op{
+		ID:          "SetAssignmentScale",
+		Tag:         "courses",
+		Summary:     "Change the grading scale of an assignment",
+		Description: "Grades already given keep their scale.",
+		Request:     courses.SetScaleRequest{},
+		Response:    courses.SetScaleResponse{},
+	})
+
+	// Exams
+	d.Add(http.MethodPost, "/exams/signup", openapi.Op{
+		ID:          "ExamSignUp",
+		Tag:         "exams",
+		Summary:     "Sign the student up for an exam",
+		Description: "Conflicts with the exams of the student and the academic calendar are returned with status Error.",
+		Request:     exams.ExamSignUpRequest{},
+		Response:    exams.ExamSignUpResponse{},
+	})
+	d.Add(http.MethodPost, "/exams/grade", openapi.Op{
+		ID:       "ExamGrade",
+		Tag:      "exams",
+		Summary:  "Grade the exam of a student on the scale of the assignment",
+		Request:  exams.ExamGradeRequest{},
+		Response: exams.ExamGradeResponse{},
+	})
+	d.Add(http.MethodGet, "/exams/rules", openapi.Op{
+		ID:       "GetExamRules",
+		Tag:      "exams",
+		Summary:  "Rules of the exam scheduling",
+		Response: exams.GetRulesResponse{},
+	})
+	d.Add(http.MethodPost, "/exams/rules/update", openapi.Op{
+		ID:       "SetExamRules",
+		Tag:      "exams",
+		Summary:  "Change the rules of the exam scheduling",
+		Request:  scheme.ExamRules{},
+		Response: exams.SetRulesResponse{},
+	})
+
+	for _, item := range d.Paths {
+		for _, op := range item {
+			op.Parameters = append(op.Parameters, activeRole)
+		}
+	}
+
+	return d
+}
+
+// Check returns an error naming the documented operations the router does not serve
+func Check(d *openapi.Document, routes chi.Routes) error {
+	routed := make(map[[2]string]bool)
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routed[[2]string{method, strings.TrimSuffix(route, "/")}] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	base := ""
+	if len(d.Servers) > 0 {
+		base = d.Servers[0].URL
+	}
+
+	missing := make([]string, 0)
+	for _, op := range d.Operations() {
+		if !routed[[2]string{op[0], base + op[1]}] {
+			missing = append(missing, op[0]+" "+base+op[1])
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("documented operations are not routed: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Spec serves the document as JSON
+func Spec(d *openapi.Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, d)
+	}
+}
+
+// Swagger UI is loaded from the CDN, the page only points it at the document
+var uiPage = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>{{.Title}}</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+	</script>
+</body>
+</html>
+`))
+
+// UI serves Swagger UI for the document at specURL
+func UI(specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		uiPage.Execute(w, struct{ Title, SpecURL string }{title, specURL})
+	}
+}
@@ -0,0 +1,912 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/api/period"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Limit of an uploaded absence document
+const maxDocumentSize = 5 << 20
+
+// Types of the documents served as uploaded, any other file is sent as
+// application/octet-stream so that the browser never renders it
+var documentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+}
+
+var (
+	ErrNotAssignmentTeacher = errors.New("not a teacher of the assignment")
+)
+
+type AssignmentGetter interface {
+	Assignment(context.Context, int64) (scheme.Assignment, error)
+}
+
+// UnitResolver locates courses and students in the org hierarchy for scoped admins
+type UnitResolver interface {
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+// teacherAssignment returns the assignment if the user teaches it
+func teacherAssignment(ctx context.Context, assignmentID int64, key *models.Key, s AssignmentGetter) (scheme.Assignment, error) {
+	assignment, err := s.Assignment(ctx, assignmentID)
+	if err != nil {
+		return scheme.Assignment{}, err
+	}
+
+	if assignment.TeacherID != key.ID {
+		return scheme.Assignment{}, ErrNotAssignmentTeacher
+	}
+
+	return assignment, nil
+}
+
+// permitsMember checks that the course or the student lies within the admin's scope
+func permitsMember(ctx context.Context, url string, key *models.Key, s UnitResolver, ac *policy.AccessControl, table string, id int64) (bool, error) {
+	unitID, err := s.UnitOf(ctx, table, id)
+	if err != nil {
+		return false, err
+	}
+
+	path, err := s.UnitPath(ctx, unitID)
+	if err != nil {
+		return false, err
+	}
+
+	return ac.Permits(url, key, path), nil
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case scheme.AttendancePresent, scheme.AttendanceAbsent, scheme.AttendanceExcused, scheme.AttendanceLate:
+		return true
+	}
+	return false
+}
+
+type SessionSaver interface {
+	SaveSession(context.Context, *scheme.ClassSession) (int64, error)
+	AssignmentGetter
+}
+
+type CreateSessionResponse struct {
+	SessionID int64 `json:"session_id"`
+	resp.Responce
+}
+
+func CreateSession(url string, log *slog.Logger, s SessionSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.CreateSession"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get assignmentID from URL
+		assignmentID, err := strconv.ParseInt(chi.URLParam(r, "assignmentID"), 10, 64)
+		if err != nil {
+			log.Info("unknown assignmentID")
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		assignment, err := teacherAssignment(r.Context(), assignmentID, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		var req scheme.ClassSession
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.SessionDate.IsZero() {
+			log.Info("session date is missing")
+			render.JSON(w, r, resp.Error("session date is required"))
+			return
+		}
+
+		req.AssignmentID = assignment.ID
+
+		id, err := s.SaveSession(r.Context(), &req)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignment.ID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save session", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save session"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateSessionResponse{
+			Responce:  resp.OK(),
+			SessionID: id,
+		})
+
+		log.Info("class session created", slog.Int64("session_id", id))
+	}
+}
+
+type SessionsGetter interface {
+	Sessions(context.Context, int64) (scheme.ClassSessions, error)
+	AssignmentGetter
+}
+
+type GetSessionsResponse struct {
+	resp.Responce
+	scheme.ClassSessions
+}
+
+func GetSessions(url string, log *slog.Logger, s SessionsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.GetSessions"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get assignmentID from URL
+		assignmentID, err := strconv.ParseInt(chi.URLParam(r, "assignmentID"), 10, 64)
+		if err != nil {
+			log.Info("unknown assignmentID")
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		assignment, err := teacherAssignment(r.Context(), assignmentID, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		sessions, err := s.Sessions(r.Context(), assignment.ID)
+		if err != nil {
+			log.Error("failed to get sessions", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get sessions"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetSessionsResponse{
+			Responce:      resp.OK(),
+			ClassSessions: sessions,
+		})
+	}
+}
+
+type AttendanceMarker interface {
+	Session(context.Context, int64) (scheme.ClassSession, error)
+	MarkAttendance(context.Context, int64, int64, []scheme.AttendanceMark) error
+	AssignmentGetter
+}
+
+type MarkResponse struct {
+	resp.Responce
+}
+
+func Mark(url string, log *slog.Logger, s AttendanceMarker, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.Mark"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get sessionID from URL
+		sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionID"), 10, 64)
+		if err != nil {
+			log.Info("unknown sessionID")
+			render.JSON(w, r, resp.Error("session not found"))
+			return
+		}
+
+		session, err := s.Session(r.Context(), sessionID)
+		if err != nil {
+			log.Info("session not found", sl.Err(err))
+			render.JSON(w, r, resp.Error("session not found"))
+			return
+		}
+
+		if _, err := teacherAssignment(r.Context(), session.AssignmentID, userAuthData, s); err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("session not found"))
+			return
+		}
+
+		var req scheme.AttendanceMarks
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		for _, mark := range req.Marks {
+			if !validStatus(mark.Status) {
+				log.Info("unknown attendance status", slog.String("status", mark.Status))
+				render.JSON(w, r, resp.Error("unknown attendance status"))
+				return
+			}
+		}
+
+		err = s.MarkAttendance(r.Context(), sessionID, userAuthData.ID, req.Marks)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", session.AssignmentID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if errors.Is(err, store.ErrNotEnrolled) {
+			log.Info("student is not enrolled", sl.Err(err))
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("failed to mark attendance", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to mark attendance"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, MarkResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("attendance marked", slog.Int64("session_id", sessionID), slog.Int("count", len(req.Marks)))
+	}
+}
+
+type AttendanceGetter interface {
+	Attendance(context.Context, scheme.AttendanceFilter) (scheme.AttendanceReport, error)
+	period.CurrentPeriodGetter
+}
+
+type StudentAttendanceGetter interface {
+	AttendanceGetter
+	UnitResolver
+}
+
+type ReportResponse struct {
+	resp.Responce
+	scheme.AttendanceReport
+}
+
+// StudentReport returns the attendance of the current student or, for staff, of the student from the URL.
+// Teachers see their own assignments only, admins the students within their scope.
+func StudentReport(url string, log *slog.Logger, s StudentAttendanceGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.StudentReport"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		studentID := userAuthData.ID
+		if param := chi.URLParam(r, "studentID"); param != "" {
+			id, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				log.Info("unknown studentID")
+				render.JSON(w, r, resp.Error("student not found"))
+				return
+			}
+			studentID = id
+		}
+
+		log = log.With(
+			slog.Int64("student_id", studentID),
+		)
+
+		// Get Period
+		p, err := period.FromRequest(r, s)
+		if err != nil {
+			log.Error("failed to get period", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid period"))
+			return
+		}
+
+		filter := scheme.AttendanceFilter{StudentID: studentID, Period: p}
+
+		switch ac.Role(url, userAuthData) {
+		case "teacher":
+			filter.TeacherID = userAuthData.ID
+		case "admin":
+			// Scope check
+			ok, err := permitsMember(r.Context(), url, userAuthData, s, ac, "users", studentID)
+			if err != nil {
+				log.Info("student not found", sl.Err(err))
+				render.JSON(w, r, resp.Error("student not found"))
+				return
+			}
+			if !ok {
+				log.Error("student is out of scope")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		report, err := s.Attendance(r.Context(), filter)
+		if err != nil {
+			log.Error("failed to get attendance", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get attendance"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, ReportResponse{
+			Responce:         resp.OK(),
+			AttendanceReport: report,
+		})
+	}
+}
+
+type CourseAttendanceGetter interface {
+	AttendanceGetter
+	UnitResolver
+}
+
+// CourseReport returns the attendance of the group, teachers see their own assignments only
+func CourseReport(url string, log *slog.Logger, s CourseAttendanceGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.CourseReport"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get courseID from URL
+		courseID, err := strconv.ParseInt(chi.URLParam(r, "courseID"), 10, 64)
+		if err != nil {
+			log.Info("unknown courseID")
+			render.JSON(w, r, resp.Error("course not found"))
+			return
+		}
+
+		// Get Period
+		p, err := period.FromRequest(r, s)
+		if err != nil {
+			log.Error("failed to get period", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid period"))
+			return
+		}
+
+		filter := scheme.AttendanceFilter{CourseID: courseID, Period: p}
+
+		switch ac.Role(url, userAuthData) {
+		case "teacher":
+			filter.TeacherID = userAuthData.ID
+		default:
+			// Scope check
+			ok, err := permitsMember(r.Context(), url, userAuthData, s, ac, "courses", courseID)
+			if err != nil {
+				log.Info("course not found", sl.Err(err))
+				render.JSON(w, r, resp.Error("course not found"))
+				return
+			}
+			if !ok {
+				log.Error("course is out of scope", slog.Int64("course_id", courseID))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		report, err := s.Attendance(r.Context(), filter)
+		if err != nil {
+			log.Error("failed to get attendance", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get attendance"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, ReportResponse{
+			Responce:         resp.OK(),
+			AttendanceReport: report,
+		})
+	}
+}
+
+type AlertsGetter interface {
+	AttendanceAlerts(context.Context, []int64, scheme.Period, float64) ([]scheme.AttendanceAlert, error)
+	AttendanceThreshold(context.Context) (float64, error)
+	period.CurrentPeriodGetter
+}
+
+type AlertsResponse struct {
+	resp.Responce
+	scheme.AttendanceAlerts
+}
+
+// Alerts returns the students above the absence threshold, ?threshold= overrides the configured one
+func Alerts(url string, log *slog.Logger, s AlertsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.Alerts"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get Period
+		p, err := period.FromRequest(r, s)
+		if err != nil {
+			log.Error("failed to get period", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid period"))
+			return
+		}
+
+		threshold, err := s.AttendanceThreshold(r.Context())
+		if err != nil {
+			log.Error("failed to get threshold", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get alerts"))
+			return
+		}
+
+		if param := r.URL.Query().Get("threshold"); param != "" {
+			threshold, err = strconv.ParseFloat(param, 64)
+			if err != nil || threshold < 0 || threshold > 100 {
+				log.Info("invalid threshold", slog.String("threshold", param))
+				render.JSON(w, r, resp.Error("invalid threshold"))
+				return
+			}
+		}
+
+		alerts, err := s.AttendanceAlerts(r.Context(), ac.Scopes(url, userAuthData), p, threshold)
+		if err != nil {
+			log.Error("failed to get alerts", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get alerts"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, AlertsResponse{
+			Responce: resp.OK(),
+			AttendanceAlerts: scheme.AttendanceAlerts{
+				Threshold: threshold,
+				Alerts:    alerts,
+			},
+		})
+	}
+}
+
+type ThresholdSetter interface {
+	SetAttendanceThreshold(context.Context, float64) error
+}
+
+type SetThresholdRequest struct {
+	Threshold float64 `json:"threshold"`
+}
+
+type SetThresholdResponse struct {
+	resp.Responce
+}
+
+func SetThreshold(url string, log *slog.Logger, s ThresholdSetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.SetThreshold"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the threshold is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req SetThresholdRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Threshold < 0 || req.Threshold > 100 {
+			log.Info("invalid threshold")
+			render.JSON(w, r, resp.Error("invalid threshold"))
+			return
+		}
+
+		if err := s.SetAttendanceThreshold(r.Context(), req.Threshold); err != nil {
+			log.Error("failed to set threshold", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set threshold"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetThresholdResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("attendance threshold changed")
+	}
+}
+
+type DocumentSaver interface {
+	SaveAbsenceDocument(context.Context, *scheme.AbsenceDocument) (int64, error)
+}
+
+type UploadDocumentResponse struct {
+	DocumentID int64 `json:"document_id"`
+	resp.Responce
+}
+
+// UploadDocument accepts multipart/form-data with the fields
+// date_from, date_to (YYYY-MM-DD), reason and the file itself in "file"
+func UploadDocument(url string, log *slog.Logger, s DocumentSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.UploadDocument"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize+1<<20)
+
+		if err := r.ParseMultipartForm(maxDocumentSize); err != nil {
+			log.Info("failed to parse form", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		dateFrom, errFrom := time.Parse(time.DateOnly, r.FormValue("date_from"))
+		dateTo, errTo := time.Parse(time.DateOnly, r.FormValue("date_to"))
+		if errFrom != nil || errTo != nil || dateTo.Before(dateFrom) {
+			log.Info("invalid document dates")
+			render.JSON(w, r, resp.Error("invalid document dates"))
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			log.Info("document file is missing", sl.Err(err))
+			render.JSON(w, r, resp.Error("document file is required"))
+			return
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			log.Error("failed to read document", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to read document"))
+			return
+		}
+
+		contentType := header.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(content)
+		}
+
+		doc := scheme.AbsenceDocument{
+			StudentID:   userAuthData.ID,
+			DateFrom:    dateFrom,
+			DateTo:      dateTo,
+			Reason:      r.FormValue("reason"),
+			FileName:    header.Filename,
+			ContentType: contentType,
+			Content:     content,
+		}
+
+		id, err := s.SaveAbsenceDocument(r.Context(), &doc)
+		if err != nil {
+			log.Error("failed to save document", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save document"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, UploadDocumentResponse{
+			Responce:   resp.OK(),
+			DocumentID: id,
+		})
+
+		log.Info("absence document uploaded", slog.Int64("document_id", id))
+	}
+}
+
+type DocumentsGetter interface {
+	AbsenceDocuments(context.Context, int64, string) (scheme.AbsenceDocuments, error)
+	UnitResolver
+}
+
+type GetDocumentsResponse struct {
+	resp.Responce
+	scheme.AbsenceDocuments
+}
+
+// GetDocuments lists the student's own documents, admins see every document within their scope filtered by ?status=
+func GetDocuments(url string, log *slog.Logger, s DocumentsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.GetDocuments"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var studentID int64
+		if ac.Role(url, userAuthData) == "student" {
+			studentID = userAuthData.ID
+		}
+
+		docs, err := s.AbsenceDocuments(r.Context(), studentID, r.URL.Query().Get("status"))
+		if err != nil {
+			log.Error("failed to get documents", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get documents"))
+			return
+		}
+
+		// Scope check
+		if studentID == 0 && ac.Scopes(url, userAuthData) != nil {
+			visible := make([]scheme.AbsenceDocument, 0, len(docs.Documents))
+			for _, doc := range docs.Documents {
+				ok, err := permitsMember(r.Context(), url, userAuthData, s, ac, "users", doc.StudentID)
+				if err != nil {
+					log.Error("failed to get student unit", sl.Err(err))
+					render.JSON(w, r, resp.Error("failed to get documents"))
+					return
+				}
+				if ok {
+					visible = append(visible, doc)
+				}
+			}
+			docs.Documents = visible
+		}
+
+		// Response
+		render.JSON(w, r, GetDocumentsResponse{
+			Responce:         resp.OK(),
+			AbsenceDocuments: docs,
+		})
+	}
+}
+
+type DocumentGetter interface {
+	AbsenceDocument(context.Context, int64) (scheme.AbsenceDocument, error)
+	UnitResolver
+}
+
+// GetDocumentFile sends the uploaded file to its owner or to an admin in scope
+func GetDocumentFile(url string, log *slog.Logger, s DocumentGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.GetDocumentFile"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get documentID from URL
+		documentID, err := strconv.ParseInt(chi.URLParam(r, "documentID"), 10, 64)
+		if err != nil {
+			log.Info("unknown documentID")
+			render.JSON(w, r, resp.Error("document not found"))
+			return
+		}
+
+		doc, err := s.AbsenceDocument(r.Context(), documentID)
+		if errors.Is(err, store.ErrDocumentNotFound) {
+			log.Info("document not found", slog.Int64("document_id", documentID))
+			render.JSON(w, r, resp.Error("document not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get document", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get document"))
+			return
+		}
+
+		// Scope check
+		if ac.Role(url, userAuthData) == "student" {
+			if doc.StudentID != userAuthData.ID {
+				log.Info("foreign document", slog.Int64("document_id", documentID))
+				render.JSON(w, r, resp.Error("document not found"))
+				return
+			}
+		} else {
+			ok, err := permitsMember(r.Context(), url, userAuthData, s, ac, "users", doc.StudentID)
+			if err != nil || !ok {
+				log.Error("document is out of scope", slog.Int64("document_id", documentID))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// The content type comes from the uploader
+		contentType := "application/octet-stream"
+		if mediaType, _, err := mime.ParseMediaType(doc.ContentType); err == nil && documentTypes[mediaType] {
+			contentType = mediaType
+		}
+
+		// Response
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(doc.FileName))
+		w.Write(doc.Content)
+	}
+}
+
+type DocumentReviewer interface {
+	AbsenceDocument(context.Context, int64) (scheme.AbsenceDocument, error)
+	ReviewAbsenceDocument(context.Context, int64, int64, bool) error
+	UnitResolver
+}
+
+type ReviewDocumentRequest struct {
+	Approved bool `json:"approved"`
+}
+
+type ReviewDocumentResponse struct {
+	resp.Responce
+}
+
+func ReviewDocument(url string, log *slog.Logger, s DocumentReviewer, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.attendance.ReviewDocument"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get documentID from URL
+		documentID, err := strconv.ParseInt(chi.URLParam(r, "documentID"), 10, 64)
+		if err != nil {
+			log.Info("unknown documentID")
+			render.JSON(w, r, resp.Error("document not found"))
+			return
+		}
+
+		doc, err := s.AbsenceDocument(r.Context(), documentID)
+		if errors.Is(err, store.ErrDocumentNotFound) {
+			log.Info("document not found", slog.Int64("document_id", documentID))
+			render.JSON(w, r, resp.Error("document not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get document", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to review document"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsMember(r.Context(), url, userAuthData, s, ac, "users", doc.StudentID)
+		if err != nil || !ok {
+			log.Error("document is out of scope", slog.Int64("document_id", documentID))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req ReviewDocumentRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		err = s.ReviewAbsenceDocument(r.Context(), documentID, userAuthData.ID, req.Approved)
+		if err != nil {
+			log.Error("failed to review document", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to review document"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, ReviewDocumentResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("absence document reviewed", slog.Int64("document_id", documentID), slog.Bool("approved", req.Approved))
+	}
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/audit"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+	dateLayout   = "2006-01-02"
+)
+
+type EntriesSearcher interface {
+	AuditEntries(context.Context, scheme.AuditFilter) (scheme.AuditEntries, error)
+}
+
+type SearchResponse struct {
+	resp.Responce
+	scheme.AuditEntries
+}
+
+// Search lists the audit log newest first. Query parameters actor_id,
+// action, entity, entity_id, from and to (dates or RFC 3339 times, to is
+// exclusive), limit and offset narrow it down.
+func Search(url string, log *slog.Logger, s EntriesSearcher, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.audit.Search"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the audit log is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			log.Info("invalid audit filter", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid filter"))
+			return
+		}
+
+		entries, err := s.AuditEntries(r.Context(), filter)
+		if err != nil {
+			log.Error("failed to search audit log", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to search audit log"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SearchResponse{
+			Responce:     resp.OK(),
+			AuditEntries: entries,
+		})
+	}
+}
+
+func parseFilter(q neturl.Values) (scheme.AuditFilter, error) {
+	f := scheme.AuditFilter{
+		Action: q.Get("action"),
+		Entity: q.Get("entity"),
+		Limit:  defaultLimit,
+	}
+
+	var err error
+
+	if v := q.Get("actor_id"); v != "" {
+		if f.ActorID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+	}
+	if v := q.Get("entity_id"); v != "" {
+		if f.EntityID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		if f.From, err = parseTime(v); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = parseTime(v); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+		f.Limit = max(1, min(f.Limit, maxLimit))
+	}
+	if v := q.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil {
+			return scheme.AuditFilter{}, err
+		}
+		f.Offset = max(0, f.Offset)
+	}
+
+	return f, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+type VerifyResponse struct {
+	resp.Responce
+	scheme.AuditVerification
+}
+
+// Verify checks the hash chain of the whole audit log
+func Verify(url string, log *slog.Logger, s audit.Chain, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.audit.Verify"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the audit log is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		res, err := audit.Verify(r.Context(), s)
+		if err != nil {
+			log.Error("failed to verify audit log", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to verify audit log"))
+			return
+		}
+
+		if !res.Valid {
+			log.Error("audit log chain is broken", slog.Int64("audit_id", res.BrokenID))
+		}
+
+		// Response
+		render.JSON(w, r, VerifyResponse{
+			Responce:          resp.OK(),
+			AuditVerification: res,
+		})
+	}
+}
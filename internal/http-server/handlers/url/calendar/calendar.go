@@ -0,0 +1,231 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/ical"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// FeedPrefix is where the feeds are served, outside of the JWT-protected routes
+const FeedPrefix = "/calendar/feeds/"
+
+// Random bytes in a token
+const tokenSize = 32
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type TokenSaver interface {
+	SaveCalendarToken(context.Context, int64, string) error
+}
+
+type IssueTokenResponse struct {
+	resp.Responce
+	scheme.CalendarToken
+}
+
+// IssueToken creates a secret feed link, the previous link of the user stops working
+func IssueToken(url string, log *slog.Logger, s TokenSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.calendar.IssueToken"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		raw := make([]byte, tokenSize)
+		if _, err := rand.Read(raw); err != nil {
+			log.Error("failed to generate token", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to issue token"))
+			return
+		}
+		token := hex.EncodeToString(raw)
+
+		if err := s.SaveCalendarToken(r.Context(), userAuthData.ID, hashToken(token)); err != nil {
+			log.Error("failed to save token", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to issue token"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, IssueTokenResponse{
+			Responce: resp.OK(),
+			CalendarToken: scheme.CalendarToken{
+				Token:   token,
+				FeedURL: FeedPrefix + token + ".ics",
+			},
+		})
+
+		log.Info("calendar token issued")
+	}
+}
+
+type TokenRevoker interface {
+	RevokeCalendarTokens(context.Context, int64) error
+}
+
+type RevokeTokenResponse struct {
+	resp.Responce
+}
+
+func RevokeToken(url string, log *slog.Logger, s TokenRevoker, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.calendar.RevokeToken"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err := s.RevokeCalendarTokens(r.Context(), userAuthData.ID)
+		if errors.Is(err, store.ErrTokenNotFound) {
+			log.Info("no active token")
+			render.JSON(w, r, resp.Error("token not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to revoke token", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to revoke token"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, RevokeTokenResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("calendar token revoked")
+	}
+}
+
+type FeedGetter interface {
+	CalendarTokenUser(context.Context, string) (models.Key, error)
+	StudentExamEvents(context.Context, int64) ([]scheme.ExamEvent, error)
+	TeacherExamEvents(context.Context, int64) ([]scheme.ExamEvent, error)
+	StudentLessonEvents(context.Context, int64) ([]scheme.LessonEvent, error)
+	TeacherLessonEvents(context.Context, int64) ([]scheme.LessonEvent, error)
+}
+
+// Feed serves the iCalendar feed of the token owner: exam registrations and
+// the timetable of a student, exam slots and the timetable of a teacher.
+// The token in the URL replaces the JWT, so the route is registered without AuthMiddleware.
+func Feed(log *slog.Logger, s FeedGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.calendar.Feed"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		key, err := s.CalendarTokenUser(r.Context(), hashToken(chi.URLParam(r, "token")))
+		if errors.Is(err, store.ErrTokenNotFound) {
+			log.Info("unknown calendar token")
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Error("failed to get token", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		events, err := feedEvents(r.Context(), s, &key)
+		if err != nil {
+			log.Error("failed to get events", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		// Response
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		w.Write(ical.Render("Журнал", events, time.Now()))
+	}
+}
+
+func feedEvents(ctx context.Context, s FeedGetter, key *models.Key) ([]ical.Event, error) {
+	var exams []scheme.ExamEvent
+	var lessons []scheme.LessonEvent
+
+	if key.Has("student") {
+		e, err := s.StudentExamEvents(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		exams = append(exams, e...)
+
+		l, err := s.StudentLessonEvents(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, l...)
+	}
+
+	if key.Has("teacher") {
+		e, err := s.TeacherExamEvents(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		exams = append(exams, e...)
+
+		l, err := s.TeacherLessonEvents(ctx, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, l...)
+	}
+
+	events := make([]ical.Event, 0, len(exams)+len(lessons))
+
+	for _, e := range exams {
+		events = append(events, ical.ExamEvent(e, key.ID))
+	}
+
+	// A student teaching their own group sees the lesson once
+	seen := make(map[int64]bool)
+	for _, l := range lessons {
+		if seen[l.ID] {
+			continue
+		}
+		seen[l.ID] = true
+
+		if event, ok := ical.LessonEvent(l, key.ID); ok {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
@@ -1,6 +1,8 @@
 package courses
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -8,21 +10,31 @@ import (
 	"github.com/arxonic/journal/internal/domain/models"
 	"github.com/arxonic/journal/internal/domain/scheme"
 	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/api/period"
 	resp "github.com/arxonic/journal/internal/lib/api/response"
 	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/gradebook"
+	"github.com/arxonic/journal/internal/services/grading"
 	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
 type CoursesGetter interface {
-	TeacherCourses(int64) (scheme.Courses, error)
-	StudentCourses(int64) (scheme.Courses, error)
-	CourseDisciplines(int64) (scheme.Disciplines, error)
-	DisciplineTeacher(int64, int64) ([]scheme.User, error)
-	AssignmentID(int64, int64, int64) (int64, error)
-	ExamsByStudentIDAndAssignmentID(int64, int64) ([]scheme.Exam, error)
-	GradeByExamID(int64) (scheme.Grade, error)
+	CurrentPeriod(context.Context) (scheme.Period, error)
+	TeacherCourses(context.Context, int64, scheme.Period) (scheme.Courses, error)
+	StudentCourses(context.Context, int64, scheme.Period) (scheme.Courses, error)
+	UnitCourses(context.Context, []int64, scheme.Period) (scheme.Courses, error)
+	CourseDisciplines(context.Context, int64, scheme.Period) (scheme.Disciplines, error)
+	DisciplineTeacher(context.Context, int64, int64) ([]scheme.User, error)
+	AssignmentID(context.Context, int64, int64, int64) (int64, error)
+	ExamsByStudentIDAndAssignmentID(context.Context, int64, int64) ([]scheme.Exam, error)
+	GradeByExamID(context.Context, int64) (scheme.Grade, error)
+	Components(context.Context, int64, int64) ([]scheme.Component, error)
+	AssignmentFormula(context.Context, int64) (string, error)
+	ScaleByCode(context.Context, string) (scheme.Scale, error)
+	ScaleConversions(context.Context) ([]scheme.Conversion, error)
 }
 
 type GetCoursesResponse struct {
@@ -34,36 +46,49 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.cources.Get"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// User Role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
-		)
+		// Get Period
+		p, err := period.FromRequest(r, s)
+		if err != nil {
+			log.Error("failed to get period", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid period"))
+			return
+		}
 
 		// Get Courses
-		courses, err := getCourses(s, userAuthData.ID, userAuthData.Role)
+		role := ac.Role(url, userAuthData)
+		courses, err := getCourses(r.Context(), s, role, userAuthData.ID, ac.Scopes(url, userAuthData), p)
 		if err != nil {
 			log.Error("failed to get courses", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to get courses"))
 			return
 		}
 
-		// TODO: Реализовать это в курсах
-		// Сверху средний бал курса, а рядом с дисциплиной оценка 1 (как в дизайне)
+		// Scales for the course average
+		fivePoint, err := s.ScaleByCode(r.Context(), scheme.ScaleFivePoint)
+		if err != nil {
+			log.Error("failed to get scale", sl.Err(err))
+		}
+
+		rules, err := s.ScaleConversions(r.Context())
+		if err != nil {
+			log.Error("failed to get scale conversions", sl.Err(err))
+		}
 
 		// Get Disciplines
 		for i, course := range courses.Courses {
-			disciplines, err := s.CourseDisciplines(course.ID)
+			disciplines, err := s.CourseDisciplines(r.Context(), course.ID, p)
 			if err != nil {
 				log.Error("failed to get disciplines", sl.Err(err))
 				continue
@@ -72,7 +97,7 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 			courses.Courses[i].Disciplines = disciplines
 
 			for j, disc := range disciplines.Disciplines {
-				teachers, err := s.DisciplineTeacher(course.ID, disc.ID)
+				teachers, err := s.DisciplineTeacher(r.Context(), course.ID, disc.ID)
 				if err != nil {
 					log.Error("failed to get teacher", sl.Err(err))
 					continue
@@ -81,13 +106,30 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 				courses.Courses[i].Disciplines.Disciplines[j].Teachers = teachers
 
 				for _, t := range teachers {
-					ass, err := s.AssignmentID(course.ID, disc.ID, t.ID)
+					ass, err := s.AssignmentID(r.Context(), course.ID, disc.ID, t.ID)
 					if err != nil {
 						log.Error("failed to get assignment", sl.Err(err))
 						continue
 					}
 
-					exams, err := s.ExamsByStudentIDAndAssignmentID(userAuthData.ID, ass)
+					components, err := s.Components(r.Context(), ass, userAuthData.ID)
+					if err != nil {
+						log.Error("failed to get components", sl.Err(err))
+					}
+
+					courses.Courses[i].Disciplines.Disciplines[j].Components = append(
+						courses.Courses[i].Disciplines.Disciplines[j].Components, components...)
+
+					if role == "student" && len(components) > 0 {
+						total, err := studentTotal(r.Context(), s, ass, components)
+						if err != nil {
+							log.Error("failed to get total", sl.Err(err))
+						} else {
+							courses.Courses[i].Disciplines.Disciplines[j].Total = &total
+						}
+					}
+
+					exams, err := s.ExamsByStudentIDAndAssignmentID(r.Context(), userAuthData.ID, ass)
 					if err != nil {
 						log.Error("failed to get exams", sl.Err(err))
 						continue
@@ -96,7 +138,7 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 					grades := make([]scheme.Grade, 0)
 
 					for _, exam := range exams {
-						grade, err := s.GradeByExamID(exam.ID)
+						grade, err := s.GradeByExamID(r.Context(), exam.ID)
 						if err != nil {
 							log.Error("failed to get grade", sl.Err(err))
 							continue
@@ -109,6 +151,18 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 					}
 				}
 			}
+
+			// Average grade of the course
+			grades := make([]scheme.Grade, 0)
+			for _, disc := range courses.Courses[i].Disciplines.Disciplines {
+				if disc.Grade.ID != 0 {
+					grades = append(grades, disc.Grade)
+				}
+			}
+
+			if avg, ok := grading.Average(rules, fivePoint.ID, grades); ok {
+				courses.Courses[i].Average = avg
+			}
 		}
 
 		// Response
@@ -119,24 +173,86 @@ func Get(url string, log *slog.Logger, s CoursesGetter, ac *policy.AccessControl
 	}
 }
 
-func getCourses(s CoursesGetter, id int64, role string) (scheme.Courses, error) {
+// studentTotal computes the total of the student from the components scored for them
+func studentTotal(ctx context.Context, s CoursesGetter, assignmentID int64, components []scheme.Component) (float64, error) {
+	formula, err := s.AssignmentFormula(ctx, assignmentID)
+	if err != nil {
+		return 0, err
+	}
+
+	scores := make(map[int64]float64, len(components))
+	for _, c := range components {
+		if c.Score != nil {
+			scores[c.ID] = *c.Score
+		}
+	}
+
+	return gradebook.Total(formula, components, scores), nil
+}
+
+func getCourses(ctx context.Context, s CoursesGetter, role string, id int64, scopes []int64, p scheme.Period) (scheme.Courses, error) {
 	var courses scheme.Courses
 	var err error
 	switch role {
 	case "teacher":
-		courses, err = s.TeacherCourses(id)
+		courses, err = s.TeacherCourses(ctx, id, p)
 	case "student":
-		courses, err = s.StudentCourses(id)
+		courses, err = s.StudentCourses(ctx, id, p)
 	case "admin":
-
+		courses, err = s.UnitCourses(ctx, scopes, p)
 	default:
 		err = policy.ErrUnauthorized
 	}
 	return courses, err
 }
 
+// UnitResolver locates courses in the org hierarchy for scoped admins
+type UnitResolver interface {
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+// permitsCourses checks that every course lies within the admin's scope
+func permitsCourses(ctx context.Context, url string, key *models.Key, s UnitResolver, ac *policy.AccessControl, courseIDs []int64) (bool, error) {
+	for _, id := range courseIDs {
+		unitID, err := s.UnitOf(ctx, "courses", id)
+		if err != nil {
+			return false, err
+		}
+
+		path, err := s.UnitPath(ctx, unitID)
+		if err != nil {
+			return false, err
+		}
+
+		if !ac.Permits(url, key, path) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// bindEnrollments puts the enrollments into the course of the URL, false if
+// one of them names another course. The URL course is the one scoped and audited.
+func bindEnrollments(enrollments *scheme.Enrollments, courseID int64) bool {
+	for i := range enrollments.Enrollments {
+		enroll := &enrollments.Enrollments[i]
+		if enroll.CourseID != 0 && enroll.CourseID != courseID {
+			return false
+		}
+		enroll.CourseID = courseID
+	}
+	return true
+}
+
+// Auditor prepares the audit entries the storage appends along with the writes
+type Auditor interface {
+	Entry(r *http.Request, action, entity string, entityID int64, before, after any) (*scheme.AuditEntry, error)
+}
+
 type CourseSaver interface {
-	SaveCourse(*scheme.CourseCreation) (int64, error)
+	SaveCourse(context.Context, *scheme.CourseCreation, *scheme.AuditEntry) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
 }
 
 type CreateCourseResponse struct {
@@ -144,26 +260,22 @@ type CreateCourseResponse struct {
 	resp.Responce
 }
 
-func Create(url string, log *slog.Logger, s CourseSaver, ac *policy.AccessControl) http.HandlerFunc {
+func Create(url string, log *slog.Logger, s CourseSaver, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.cources.Create"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// Role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
-		)
-
 		var req scheme.CourseCreation
 
 		err := render.DecodeJSON(r.Body, &req)
@@ -173,7 +285,49 @@ func Create(url string, log *slog.Logger, s CourseSaver, ac *policy.AccessContro
 			return
 		}
 
-		id, err := s.SaveCourse(&req)
+		// Scope check
+		path, err := s.UnitPath(r.Context(), req.UnitID)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("unit not found", slog.Int64("unit_id", req.UnitID))
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get unit", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save course"))
+			return
+		}
+
+		if !ac.Permits(url, userAuthData, path) {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// The storage records the ID of the new course
+		entry, err := a.Entry(r, scheme.AuditCourseCreate, "course", 0, nil, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save course"))
+			return
+		}
+
+		id, err := s.SaveCourse(r.Context(), &req, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("academic year is archived", slog.Int64("academic_year_id", req.AcademicYearID))
+			render.JSON(w, r, resp.Error("academic year is archived"))
+			return
+		}
+		if errors.Is(err, store.ErrPeriodNotFound) {
+			log.Info("period not found", slog.Int64("academic_year_id", req.AcademicYearID))
+			render.JSON(w, r, resp.Error("period not found"))
+			return
+		}
+		if errors.Is(err, store.ErrInvalidSemester) {
+			log.Info("semester outside of the academic year", slog.Int64("academic_year_id", req.AcademicYearID))
+			render.JSON(w, r, resp.Error("semester does not belong to the academic year of the course"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to save course", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to save course"))
@@ -190,25 +344,48 @@ func Create(url string, log *slog.Logger, s CourseSaver, ac *policy.AccessContro
 	}
 }
 
+// Notifier queues notifications of domain events, it never fails the request
+type Notifier interface {
+	Notify(context.Context, string, int64, scheme.NotificationData)
+}
+
+// Publisher sends live updates to the connected users
+type Publisher interface {
+	Publish(string, any, ...int64)
+}
+
+// notifyEnrollments notifies every student of the enrollments by email and live update
+func notifyEnrollments(ctx context.Context, n Notifier, p Publisher, kind string, enrollments *scheme.Enrollments) {
+	for _, enroll := range enrollments.Enrollments {
+		n.Notify(ctx, kind, enroll.StudentID, scheme.NotificationData{CourseID: enroll.CourseID})
+
+		p.Publish(scheme.LiveEnrollmentChanged, scheme.EnrollmentUpdate{
+			CourseID: enroll.CourseID,
+			Enrolled: kind == scheme.NotifyEnrolled,
+		}, enroll.StudentID)
+	}
+}
+
 type StudentsEnroller interface {
-	EnrollStudents(*scheme.Enrollments) error
+	EnrollStudents(context.Context, *scheme.Enrollments, *scheme.AuditEntry) error
+	UnitResolver
 }
 
 type EnrollStudentsResponse struct {
 	resp.Responce
 }
 
-func EnrollStudents(url string, log *slog.Logger, s StudentsEnroller, ac *policy.AccessControl) http.HandlerFunc {
+func EnrollStudents(url string, log *slog.Logger, s StudentsEnroller, n Notifier, p Publisher, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.cources.EnrollStudents"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// Role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -227,7 +404,6 @@ func EnrollStudents(url string, log *slog.Logger, s StudentsEnroller, ac *policy
 		courseID := int64(_courseID)
 
 		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
 			slog.Int64("course_id", courseID),
 		)
 
@@ -240,7 +416,38 @@ func EnrollStudents(url string, log *slog.Logger, s StudentsEnroller, ac *policy
 			return
 		}
 
-		err = s.EnrollStudents(&req)
+		if !bindEnrollments(&req, courseID) {
+			log.Info("enrollment of another course")
+			render.JSON(w, r, resp.Error("enrollments must belong to the course"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsCourses(r.Context(), url, userAuthData, s, ac, []int64{courseID})
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("course not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entry, err := a.Entry(r, scheme.AuditStudentsEnroll, "course", courseID, nil, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to enroll students"))
+			return
+		}
+
+		err = s.EnrollStudents(r.Context(), &req, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("course is archived")
+			render.JSON(w, r, resp.Error("course is archived"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to enroll students", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to enroll students"))
@@ -253,28 +460,31 @@ func EnrollStudents(url string, log *slog.Logger, s StudentsEnroller, ac *policy
 		})
 
 		log.Info("students enrolled")
+
+		notifyEnrollments(r.Context(), n, p, scheme.NotifyEnrolled, &req)
 	}
 }
 
 type StudentsRemover interface {
-	RemoveStudents(*scheme.Enrollments) error
+	RemoveStudents(context.Context, *scheme.Enrollments, *scheme.AuditEntry) error
+	UnitResolver
 }
 
 type RemoveStudentsResponse struct {
 	resp.Responce
 }
 
-func RemoveStudents(url string, log *slog.Logger, s StudentsRemover, ac *policy.AccessControl) http.HandlerFunc {
+func RemoveStudents(url string, log *slog.Logger, s StudentsRemover, n Notifier, p Publisher, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.cources.RemoveStudents"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// User role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
@@ -291,7 +501,6 @@ func RemoveStudents(url string, log *slog.Logger, s StudentsRemover, ac *policy.
 		}
 
 		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
 			slog.Int("course_id", courseID),
 		)
 
@@ -304,7 +513,38 @@ func RemoveStudents(url string, log *slog.Logger, s StudentsRemover, ac *policy.
 			return
 		}
 
-		err = s.RemoveStudents(&req)
+		if !bindEnrollments(&req, int64(courseID)) {
+			log.Info("enrollment of another course")
+			render.JSON(w, r, resp.Error("enrollments must belong to the course"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsCourses(r.Context(), url, userAuthData, s, ac, []int64{int64(courseID)})
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("course not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entry, err := a.Entry(r, scheme.AuditStudentsRemove, "course", int64(courseID), req, nil)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to remove students"))
+			return
+		}
+
+		err = s.RemoveStudents(r.Context(), &req, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("course is archived")
+			render.JSON(w, r, resp.Error("course is archived"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to remove students", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to remove students"))
@@ -317,5 +557,107 @@ func RemoveStudents(url string, log *slog.Logger, s StudentsRemover, ac *policy.
 		})
 
 		log.Info("students removed")
+
+		notifyEnrollments(r.Context(), n, p, scheme.NotifyUnenrolled, &req)
+	}
+}
+
+type AssignmentScaleSetter interface {
+	Assignment(context.Context, int64) (scheme.Assignment, error)
+	SetAssignmentScale(context.Context, int64, int64, *scheme.AuditEntry) error
+	UnitResolver
+}
+
+type SetScaleRequest struct {
+	ScaleID int64 `json:"scale_id"`
+}
+
+type SetScaleResponse struct {
+	resp.Responce
+}
+
+// SetScale changes the grading scale of the assignment. Grades already given keep their scale.
+func SetScale(url string, log *slog.Logger, s AssignmentScaleSetter, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.cources.SetScale"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get assignmentID from URL
+		assignmentID, err := strconv.ParseInt(chi.URLParam(r, "assignmentID"), 10, 64)
+		if err != nil {
+			log.Info("unknown assignmentID")
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("assignment_id", assignmentID),
+		)
+
+		var req SetScaleRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		assignment, err := s.Assignment(r.Context(), assignmentID)
+		if err != nil {
+			log.Info("assignment not found", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsCourses(r.Context(), url, userAuthData, s, ac, []int64{assignment.CourseID})
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("course not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entry, err := a.Entry(r, scheme.AuditScaleSet, "assignment", assignmentID, SetScaleRequest{ScaleID: assignment.ScaleID}, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set scale"))
+			return
+		}
+
+		err = s.SetAssignmentScale(r.Context(), assignmentID, req.ScaleID, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived")
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to set scale", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set scale"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetScaleResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("assignment scale changed", slog.Int64("scale_id", req.ScaleID))
 	}
 }
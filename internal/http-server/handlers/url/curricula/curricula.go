@@ -0,0 +1,516 @@
+package curricula
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/arxonic/journal/internal/services/progress"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrInvalidCurriculum = errors.New("invalid curriculum")
+)
+
+type ProgrammesGetter interface {
+	Programmes(context.Context) (scheme.Programmes, error)
+}
+
+type GetProgrammesResponse struct {
+	resp.Responce
+	scheme.Programmes
+}
+
+func GetProgrammes(url string, log *slog.Logger, s ProgrammesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.GetProgrammes"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		programmes, err := s.Programmes(r.Context())
+		if err != nil {
+			log.Error("failed to get programmes", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get programmes"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetProgrammesResponse{
+			Responce:   resp.OK(),
+			Programmes: programmes,
+		})
+	}
+}
+
+type ProgrammeSaver interface {
+	SaveProgramme(context.Context, *scheme.Programme) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+type CreateProgrammeResponse struct {
+	ProgrammeID int64 `json:"programme_id"`
+	resp.Responce
+}
+
+func CreateProgramme(url string, log *slog.Logger, s ProgrammeSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.CreateProgramme"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Programme
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Code == "" || req.Name == "" {
+			log.Info("invalid programme")
+			render.JSON(w, r, resp.Error("invalid programme"))
+			return
+		}
+
+		// Scope check
+		path, err := s.UnitPath(r.Context(), req.UnitID)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("unit not found", slog.Int64("unit_id", req.UnitID))
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get unit", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save programme"))
+			return
+		}
+
+		if !ac.Permits(url, userAuthData, path) {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := s.SaveProgramme(r.Context(), &req)
+		if err != nil {
+			log.Error("failed to save programme", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save programme"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateProgrammeResponse{
+			Responce:    resp.OK(),
+			ProgrammeID: id,
+		})
+
+		log.Info("programme created", slog.Int64("programme_id", id))
+	}
+}
+
+// ProgrammeScope locates a programme in the org hierarchy for scoped admins
+type ProgrammeScope interface {
+	Programme(context.Context, int64) (scheme.Programme, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+func permitsProgramme(ctx context.Context, url string, key *models.Key, s ProgrammeScope, ac *policy.AccessControl, programmeID int64) (bool, error) {
+	programme, err := s.Programme(ctx, programmeID)
+	if err != nil {
+		return false, err
+	}
+
+	path, err := s.UnitPath(ctx, programme.UnitID)
+	if err != nil {
+		return false, err
+	}
+
+	return ac.Permits(url, key, path), nil
+}
+
+func validate(c *scheme.Curriculum) error {
+	if c.Name == "" || c.AdmissionYear <= 0 || c.ElectiveCredits < 0 {
+		return ErrInvalidCurriculum
+	}
+
+	for _, item := range c.Items {
+		if item.Kind != scheme.ItemRequired && item.Kind != scheme.ItemElective {
+			return ErrInvalidCurriculum
+		}
+
+		switch item.Assessment {
+		case scheme.AssessmentExam, scheme.AssessmentPassFail, scheme.AssessmentCoursework:
+		default:
+			return ErrInvalidCurriculum
+		}
+
+		if item.CreditHours <= 0 || item.Semester < 1 || item.Semester > 12 {
+			return ErrInvalidCurriculum
+		}
+	}
+
+	return nil
+}
+
+type CurriculumSaver interface {
+	SaveCurriculum(context.Context, *scheme.Curriculum) (int64, error)
+	ProgrammeScope
+}
+
+type CreateCurriculumResponse struct {
+	CurriculumID int64 `json:"curriculum_id"`
+	resp.Responce
+}
+
+func Create(url string, log *slog.Logger, s CurriculumSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.Create"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Curriculum
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validate(&req); err != nil {
+			log.Info("invalid curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid curriculum"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsProgramme(r.Context(), url, userAuthData, s, ac, req.ProgrammeID)
+		if errors.Is(err, store.ErrProgrammeNotFound) {
+			log.Info("programme not found", slog.Int64("programme_id", req.ProgrammeID))
+			render.JSON(w, r, resp.Error("programme not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save curriculum"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := s.SaveCurriculum(r.Context(), &req)
+		if err != nil {
+			log.Error("failed to save curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save curriculum"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateCurriculumResponse{
+			Responce:     resp.OK(),
+			CurriculumID: id,
+		})
+
+		log.Info("curriculum created", slog.Int64("curriculum_id", id))
+	}
+}
+
+type CurriculumGetter interface {
+	Curriculum(context.Context, int64) (scheme.Curriculum, error)
+}
+
+type GetCurriculumResponse struct {
+	resp.Responce
+	scheme.Curriculum
+}
+
+func Get(url string, log *slog.Logger, s CurriculumGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get curriculumID from URL
+		curriculumID, err := strconv.ParseInt(chi.URLParam(r, "curriculumID"), 10, 64)
+		if err != nil {
+			log.Info("unknown curriculumID")
+			render.JSON(w, r, resp.Error("curriculum not found"))
+			return
+		}
+
+		c, err := s.Curriculum(r.Context(), curriculumID)
+		if errors.Is(err, store.ErrCurriculumNotFound) {
+			log.Info("curriculum not found", slog.Int64("curriculum_id", curriculumID))
+			render.JSON(w, r, resp.Error("curriculum not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get curriculum"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetCurriculumResponse{
+			Responce:   resp.OK(),
+			Curriculum: c,
+		})
+	}
+}
+
+type CurriculumStudentsSaver interface {
+	Curriculum(context.Context, int64) (scheme.Curriculum, error)
+	SaveCurriculumStudents(context.Context, int64, []int64) error
+	ProgrammeScope
+}
+
+type SaveStudentsResponse struct {
+	resp.Responce
+}
+
+func SaveStudents(url string, log *slog.Logger, s CurriculumStudentsSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.SaveStudents"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get curriculumID from URL
+		curriculumID, err := strconv.ParseInt(chi.URLParam(r, "curriculumID"), 10, 64)
+		if err != nil {
+			log.Info("unknown curriculumID")
+			render.JSON(w, r, resp.Error("curriculum not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("curriculum_id", curriculumID),
+		)
+
+		var req scheme.CurriculumStudents
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		c, err := s.Curriculum(r.Context(), curriculumID)
+		if errors.Is(err, store.ErrCurriculumNotFound) {
+			log.Info("curriculum not found")
+			render.JSON(w, r, resp.Error("curriculum not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save students"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsProgramme(r.Context(), url, userAuthData, s, ac, c.ProgrammeID)
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save students"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = s.SaveCurriculumStudents(r.Context(), curriculumID, req.StudentIDs)
+		if err != nil {
+			log.Error("failed to save students", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save students"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SaveStudentsResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("students put on curriculum")
+	}
+}
+
+type ProgressGetter interface {
+	StudentCurriculumID(context.Context, int64) (int64, error)
+	Curriculum(context.Context, int64) (scheme.Curriculum, error)
+	StudentGrades(context.Context, int64) (map[int64]scheme.Grade, error)
+	TeachesStudent(context.Context, int64, int64) (bool, error)
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+// permitsStudent checks that teachers teach the student and that the student
+// lies within the scope of admins
+func permitsStudent(ctx context.Context, url string, key *models.Key, s ProgressGetter, ac *policy.AccessControl, studentID int64) (bool, error) {
+	if ac.Role(url, key) == "teacher" {
+		return s.TeachesStudent(ctx, key.ID, studentID)
+	}
+
+	unitID, err := s.UnitOf(ctx, "users", studentID)
+	if err != nil {
+		return false, err
+	}
+
+	path, err := s.UnitPath(ctx, unitID)
+	if err != nil {
+		return false, err
+	}
+
+	return ac.Permits(url, key, path), nil
+}
+
+type ProgressResponse struct {
+	resp.Responce
+	scheme.Progress
+}
+
+// Progress shows the progress of the student against the curriculum.
+// Students see their own progress, staff pass the student in the URL:
+// teachers the students of their courses, admins those of their units.
+func Progress(url string, log *slog.Logger, s ProgressGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.curricula.Progress"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		studentID := userAuthData.ID
+		if param := chi.URLParam(r, "studentID"); param != "" {
+			id, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				log.Info("unknown studentID")
+				render.JSON(w, r, resp.Error("student not found"))
+				return
+			}
+			studentID = id
+
+			ok, err := permitsStudent(r.Context(), url, userAuthData, s, ac, studentID)
+			if err != nil {
+				log.Info("failed to check student access", sl.Err(err))
+				render.JSON(w, r, resp.Error("student not found"))
+				return
+			}
+			if !ok {
+				log.Error("student outside of user scope", sl.Err(policy.ErrUnauthorized))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		log = log.With(
+			slog.Int64("student_id", studentID),
+		)
+
+		curriculumID, err := s.StudentCurriculumID(r.Context(), studentID)
+		if errors.Is(err, store.ErrCurriculumNotFound) {
+			log.Info("student has no curriculum")
+			render.JSON(w, r, resp.Error("curriculum not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get student curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get progress"))
+			return
+		}
+
+		c, err := s.Curriculum(r.Context(), curriculumID)
+		if err != nil {
+			log.Error("failed to get curriculum", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get progress"))
+			return
+		}
+
+		grades, err := s.StudentGrades(r.Context(), studentID)
+		if err != nil {
+			log.Error("failed to get grades", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get progress"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, ProgressResponse{
+			Responce: resp.OK(),
+			Progress: progress.Compute(studentID, c, grades),
+		})
+	}
+}
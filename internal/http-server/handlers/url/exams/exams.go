@@ -1,6 +1,9 @@
 package exams
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
@@ -10,17 +13,40 @@ import (
 	"github.com/arxonic/journal/internal/http-server/middleware/auth"
 	resp "github.com/arxonic/journal/internal/lib/api/response"
 	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/arxonic/journal/internal/services/grading"
 	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/arxonic/journal/internal/services/scheduling"
+	store "github.com/arxonic/journal/internal/storage"
 	"github.com/go-chi/render"
 )
 
+// Notifier queues notifications of domain events, it never fails the request
+type Notifier interface {
+	Notify(context.Context, string, int64, scheme.NotificationData)
+}
+
+// Publisher sends live updates to the connected users
+type Publisher interface {
+	Publish(string, any, ...int64)
+}
+
+// Auditor prepares the audit entries the storage appends along with the writes
+type Auditor interface {
+	Entry(r *http.Request, action, entity string, entityID int64, before, after any) (*scheme.AuditEntry, error)
+}
+
 type ExamSignUper interface {
-	ExamSignUp(int64, int64, time.Time) error
-	AssignmentID(int64, int64, int64) (int64, error)
+	ExamSignUp(context.Context, int64, int64, time.Time, func([]scheme.ExamEvent) []scheme.ExamConflict, *scheme.AuditEntry) ([]scheme.ExamConflict, error)
+	AssignmentID(context.Context, int64, int64, int64) (int64, error)
+	AssignmentYearID(context.Context, int64) (int64, error)
+	AcademicEvents(context.Context, int64, string) (scheme.AcademicEvents, error)
+	ExamRules(context.Context) (scheme.ExamRules, error)
 }
 
 type ExamSignUpResponse struct {
 	resp.Responce
+	Conflicts []scheme.ExamConflict `json:"conflicts,omitempty"`
 }
 
 type ExamSignUpRequest struct {
@@ -28,26 +54,22 @@ type ExamSignUpRequest struct {
 	ExamDate time.Time `json:"exam_date"`
 }
 
-func ExamSignUp(url string, log *slog.Logger, s ExamSignUper, ac *policy.AccessControl) http.HandlerFunc {
+func ExamSignUp(url string, log *slog.Logger, s ExamSignUper, n Notifier, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.exams.ExamSignUp"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// User Role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
-		)
-
 		var req ExamSignUpRequest
 
 		err := render.DecodeJSON(r.Body, &req)
@@ -57,32 +79,97 @@ func ExamSignUp(url string, log *slog.Logger, s ExamSignUper, ac *policy.AccessC
 			return
 		}
 
-		assignmentID, err := s.AssignmentID(req.CourseID, req.DisciplineID, req.TeacherID)
+		assignmentID, err := s.AssignmentID(r.Context(), req.CourseID, req.DisciplineID, req.TeacherID)
 		if err != nil {
 			log.Error("failed to decode get assignmentID", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to decode request"))
 			return
 		}
 
+		// The conflicts with the exams of the student are checked by the storage
+		// within the transaction of the sign-up
+		check, err := examCheck(r.Context(), s, assignmentID, req.ExamDate)
+		if err != nil {
+			log.Error("failed to check exam conflicts", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to sign up for the exam"))
+			return
+		}
+
 		// Exam sign up
-		err = s.ExamSignUp(userAuthData.ID, assignmentID, req.ExamDate)
+		req.ID = assignmentID
+
+		entry, err := a.Entry(r, scheme.AuditExamSignUp, "assignment", assignmentID, nil, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to sign up for the exam"))
+			return
+		}
+
+		conflicts, err := s.ExamSignUp(r.Context(), userAuthData.ID, assignmentID, req.ExamDate, check, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignmentID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to sign up for the exam", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to sign up for the exam"))
 			return
 		}
+		if len(conflicts) > 0 {
+			log.Info("exam conflicts", slog.Int64("assignment_id", assignmentID), slog.Int("conflicts", len(conflicts)))
+			render.JSON(w, r, ExamSignUpResponse{
+				Responce:  resp.Error("exam conflicts with the schedule"),
+				Conflicts: conflicts,
+			})
+			return
+		}
+
+		metrics.ExamSignUps.Inc()
 
 		// Response
 		render.JSON(w, r, ExamSignUpResponse{
 			Responce: resp.OK(),
 		})
+
+		n.Notify(r.Context(), scheme.NotifyExamSignUp, req.TeacherID, scheme.NotificationData{
+			StudentID:    userAuthData.ID,
+			CourseID:     req.CourseID,
+			DisciplineID: req.DisciplineID,
+			ExamDate:     req.ExamDate,
+		})
+	}
+}
+
+// examCheck returns the conflicts of the exam with the booked exams of the
+// student, the academic calendar and the rules of the scheduling
+func examCheck(ctx context.Context, s ExamSignUper, assignmentID int64, date time.Time) (func([]scheme.ExamEvent) []scheme.ExamConflict, error) {
+	// The calendar of the academic year the exam belongs to
+	yearID, err := s.AssignmentYearID(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.AcademicEvents(ctx, yearID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.ExamRules(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	return func(booked []scheme.ExamEvent) []scheme.ExamConflict {
+		return scheduling.ExamConflicts(date, time.Now(), booked, events.Events, rules)
+	}, nil
 }
 
 type ExamGrader interface {
-	AssignmentID(int64, int64, int64) (int64, error)
-	ExamID(int64, int64, time.Time) (int64, error)
-	ExamGrade(int64, int64, int, time.Time) error
+	AssignmentID(context.Context, int64, int64, int64) (int64, error)
+	AssignmentScale(context.Context, int64) (scheme.Scale, error)
+	ExamID(context.Context, int64, int64, time.Time) (int64, error)
+	ExamGrade(context.Context, int64, int64, int, time.Time, *scheme.AuditEntry) error
 }
 
 type ExamGradeResponse struct {
@@ -96,26 +183,22 @@ type ExamGradeRequest struct {
 	ExamDate  time.Time `json:"grade_date"`
 }
 
-func ExamGrade(url string, log *slog.Logger, s ExamGrader, ac *policy.AccessControl) http.HandlerFunc {
+func ExamGrade(url string, log *slog.Logger, s ExamGrader, n Notifier, p Publisher, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const fn = "http-server.handlers.url.exams.ExamGrade"
 
-		log = log.With(
+		log := sl.FromContext(r.Context(), log).With(
 			slog.String("fn", fn),
 		)
 
 		// User Role check
 		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
-		if !ac.Contains(url, userAuthData.Role) {
+		if !ac.Contains(url, userAuthData) {
 			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		log = log.With(
-			slog.Int64("user_id", userAuthData.ID),
-		)
-
 		var req ExamGradeRequest
 
 		err := render.DecodeJSON(r.Body, &req)
@@ -126,15 +209,29 @@ func ExamGrade(url string, log *slog.Logger, s ExamGrader, ac *policy.AccessCont
 		}
 
 		// Get AssignmentID
-		assignmentID, err := s.AssignmentID(req.CourseID, req.DisciplineID, userAuthData.ID)
+		assignmentID, err := s.AssignmentID(r.Context(), req.CourseID, req.DisciplineID, userAuthData.ID)
 		if err != nil {
 			log.Error("failed to decode get assignmentID", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to decode request"))
 			return
 		}
 
+		// Check the grade against the assignment scale
+		scale, err := s.AssignmentScale(r.Context(), assignmentID)
+		if err != nil {
+			log.Error("failed to get scale", sl.Err(err))
+			render.JSON(w, r, resp.Error("error in rating"))
+			return
+		}
+
+		if !grading.Valid(scale, int64(req.Grade)) {
+			log.Info("grade is out of scale", slog.Int("grade", req.Grade), slog.String("scale", scale.Code))
+			render.JSON(w, r, resp.Error(fmt.Sprintf("grade must be between %d and %d", scale.Min, scale.Max)))
+			return
+		}
+
 		// Get ExamID
-		examID, err := s.ExamID(req.StudentID, assignmentID, req.ExamDate)
+		examID, err := s.ExamID(r.Context(), req.StudentID, assignmentID, req.ExamDate)
 		if err != nil {
 			log.Error("failed to get exam", sl.Err(err))
 			render.JSON(w, r, resp.Error("failed to get exam"))
@@ -142,25 +239,56 @@ func ExamGrade(url string, log *slog.Logger, s ExamGrader, ac *policy.AccessCont
 		}
 
 		// Grading
-		err = s.ExamGrade(examID, userAuthData.ID, req.Grade, req.ExamDate)
+		req.ID = assignmentID
+		req.TeacherID = userAuthData.ID
+
+		entry, err := a.Entry(r, scheme.AuditExamGrade, "exam", examID, nil, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("error in rating"))
+			return
+		}
+
+		err = s.ExamGrade(r.Context(), examID, userAuthData.ID, req.Grade, req.ExamDate, entry)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignmentID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to set exam grade", sl.Err(err))
 			render.JSON(w, r, resp.Error("error in rating"))
 			return
 		}
 
+		metrics.GradesPosted.Inc()
+
 		// Response
 		render.JSON(w, r, ExamSignUpResponse{
 			Responce: resp.OK(),
 		})
+
+		n.Notify(r.Context(), scheme.NotifyExamGraded, req.StudentID, scheme.NotificationData{
+			CourseID:     req.CourseID,
+			DisciplineID: req.DisciplineID,
+			ExamDate:     req.ExamDate,
+			Grade:        req.Grade,
+		})
+
+		p.Publish(scheme.LiveGradePosted, scheme.GradeUpdate{
+			CourseID:     req.CourseID,
+			DisciplineID: req.DisciplineID,
+			Grade:        req.Grade,
+			ExamDate:     req.ExamDate,
+		}, req.StudentID)
 	}
 }
 
 type Grader interface {
 	ExamByStudentID(int64) (scheme.Exam, error)
 	GradesByExamID(int64) (scheme.Grade, error)
-	Assignment(int64) (scheme.Assignment, error)
-	ExamGrade(int64, int64, int, time.Time) error
+	Assignment(context.Context, int64) (scheme.Assignment, error)
+	ExamGrade(context.Context, int64, int64, int, time.Time, *scheme.AuditEntry) error
 }
 
 type GradesResponse struct {
@@ -173,3 +301,112 @@ type GradesRequest struct {
 	Grade     int       `json:"grade"`
 	ExamDate  time.Time `json:"grade_date"`
 }
+
+type RulesGetter interface {
+	ExamRules(context.Context) (scheme.ExamRules, error)
+}
+
+type GetRulesResponse struct {
+	resp.Responce
+	scheme.ExamRules
+}
+
+func GetRules(url string, log *slog.Logger, s RulesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.exams.GetRules"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rules, err := s.ExamRules(r.Context())
+		if err != nil {
+			log.Error("failed to get exam rules", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get exam rules"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetRulesResponse{
+			Responce:  resp.OK(),
+			ExamRules: rules,
+		})
+	}
+}
+
+type RulesSetter interface {
+	ExamRules(context.Context) (scheme.ExamRules, error)
+	SetExamRules(context.Context, scheme.ExamRules, *scheme.AuditEntry) error
+}
+
+type SetRulesResponse struct {
+	resp.Responce
+}
+
+func SetRules(url string, log *slog.Logger, s RulesSetter, a Auditor, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.exams.SetRules"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, exam rules are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.ExamRules
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.MinGapDays < 0 || req.DurationMinutes <= 0 {
+			log.Info("invalid exam rules")
+			render.JSON(w, r, resp.Error("invalid exam rules"))
+			return
+		}
+
+		before, err := s.ExamRules(r.Context())
+		if err != nil {
+			log.Error("failed to get exam rules", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set exam rules"))
+			return
+		}
+
+		entry, err := a.Entry(r, scheme.AuditExamRulesUpdate, "exam_rules", 0, before, req)
+		if err != nil {
+			log.Error("failed to prepare audit entry", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set exam rules"))
+			return
+		}
+
+		if err := s.SetExamRules(r.Context(), req, entry); err != nil {
+			log.Error("failed to set exam rules", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set exam rules"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetRulesResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("exam rules changed", slog.Int("min_gap_days", req.MinGapDays), slog.Int("duration_minutes", req.DurationMinutes))
+	}
+}
@@ -0,0 +1,382 @@
+package gradebook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/gradebook"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrNotAssignmentTeacher = errors.New("not a teacher of the assignment")
+)
+
+type AssignmentGetter interface {
+	Assignment(context.Context, int64) (scheme.Assignment, error)
+}
+
+// teacherAssignment returns the assignment from the URL if the user teaches it
+func teacherAssignment(r *http.Request, key *models.Key, s AssignmentGetter) (scheme.Assignment, error) {
+	assignmentID, err := strconv.ParseInt(chi.URLParam(r, "assignmentID"), 10, 64)
+	if err != nil {
+		return scheme.Assignment{}, err
+	}
+
+	assignment, err := s.Assignment(r.Context(), assignmentID)
+	if err != nil {
+		return scheme.Assignment{}, err
+	}
+
+	if assignment.TeacherID != key.ID {
+		return scheme.Assignment{}, ErrNotAssignmentTeacher
+	}
+
+	return assignment, nil
+}
+
+type ComponentSaver interface {
+	SaveComponent(context.Context, *scheme.Component) (int64, error)
+	AssignmentGetter
+}
+
+type CreateComponentResponse struct {
+	ComponentID int64 `json:"component_id"`
+	resp.Responce
+}
+
+func CreateComponent(url string, log *slog.Logger, s ComponentSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.gradebook.CreateComponent"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		assignment, err := teacherAssignment(r, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		var req scheme.Component
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Name == "" || req.Weight <= 0 || req.MaxScore <= 0 {
+			log.Info("invalid component")
+			render.JSON(w, r, resp.Error("invalid component"))
+			return
+		}
+
+		req.AssignmentID = assignment.ID
+
+		id, err := s.SaveComponent(r.Context(), &req)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignment.ID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save component", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save component"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateComponentResponse{
+			Responce:    resp.OK(),
+			ComponentID: id,
+		})
+
+		log.Info("component created", slog.Int64("component_id", id))
+	}
+}
+
+type ScoresSaver interface {
+	SaveScores(context.Context, int64, int64, []scheme.Score) error
+	AssignmentGetter
+}
+
+type SaveScoresResponse struct {
+	resp.Responce
+}
+
+func SaveScores(url string, log *slog.Logger, s ScoresSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.gradebook.SaveScores"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		assignment, err := teacherAssignment(r, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		var req scheme.Scores
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		err = s.SaveScores(r.Context(), assignment.ID, userAuthData.ID, req.Scores)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignment.ID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if errors.Is(err, store.ErrComponentNotFound) || errors.Is(err, store.ErrInvalidScore) {
+			log.Info("invalid scores", sl.Err(err))
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save scores", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save scores"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SaveScoresResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("scores saved", slog.Int64("assignment_id", assignment.ID), slog.Int("count", len(req.Scores)))
+	}
+}
+
+type FormulaSetter interface {
+	SetAssignmentFormula(context.Context, int64, string) error
+	AssignmentGetter
+}
+
+type SetFormulaRequest struct {
+	Formula string `json:"final_formula"`
+}
+
+type SetFormulaResponse struct {
+	resp.Responce
+}
+
+func SetFormula(url string, log *slog.Logger, s FormulaSetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.gradebook.SetFormula"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		assignment, err := teacherAssignment(r, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		var req SetFormulaRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Formula != scheme.FormulaWeightedMean && req.Formula != scheme.FormulaWeightedSum {
+			log.Info("unknown formula", slog.String("formula", req.Formula))
+			render.JSON(w, r, resp.Error("unknown formula"))
+			return
+		}
+
+		err = s.SetAssignmentFormula(r.Context(), assignment.ID, req.Formula)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignment.ID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to set formula", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set formula"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetFormulaResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("formula changed", slog.Int64("assignment_id", assignment.ID), slog.String("formula", req.Formula))
+	}
+}
+
+type GradebookGetter interface {
+	Components(context.Context, int64, int64) ([]scheme.Component, error)
+	AssignmentFormula(context.Context, int64) (string, error)
+	AssignmentStudents(context.Context, int64) ([]int64, error)
+	AssignmentScores(context.Context, int64) (map[int64]map[int64]float64, error)
+	AssignmentScale(context.Context, int64) (scheme.Scale, error)
+	ScaleByCode(context.Context, string) (scheme.Scale, error)
+	ScaleConversions(context.Context) ([]scheme.Conversion, error)
+	AssignmentGetter
+}
+
+type GetGradebookResponse struct {
+	resp.Responce
+	scheme.Gradebook
+}
+
+// Get returns the scores of every enrolled student with the total and the suggested final grade
+func Get(url string, log *slog.Logger, s GradebookGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.gradebook.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		assignment, err := teacherAssignment(r, userAuthData, s)
+		if err != nil {
+			log.Info("assignment not available", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		book, err := buildGradebook(r.Context(), s, assignment.ID)
+		if err != nil {
+			log.Error("failed to get gradebook", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get gradebook"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetGradebookResponse{
+			Responce:  resp.OK(),
+			Gradebook: book,
+		})
+	}
+}
+
+func buildGradebook(ctx context.Context, s GradebookGetter, assignmentID int64) (scheme.Gradebook, error) {
+	components, err := s.Components(ctx, assignmentID, 0)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	formula, err := s.AssignmentFormula(ctx, assignmentID)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	students, err := s.AssignmentStudents(ctx, assignmentID)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	scores, err := s.AssignmentScores(ctx, assignmentID)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	scale, err := s.AssignmentScale(ctx, assignmentID)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	rating, err := s.ScaleByCode(ctx, scheme.ScaleRating100)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	rules, err := s.ScaleConversions(ctx)
+	if err != nil {
+		return scheme.Gradebook{}, err
+	}
+
+	book := scheme.Gradebook{
+		AssignmentID: assignmentID,
+		Formula:      formula,
+		Components:   components,
+		Students:     make([]scheme.StudentGradebook, 0, len(students)),
+	}
+
+	for _, studentID := range students {
+		sg := scheme.StudentGradebook{
+			StudentID: studentID,
+			Scores:    make([]scheme.Score, 0),
+		}
+
+		for _, c := range components {
+			if score, ok := scores[studentID][c.ID]; ok {
+				sg.Scores = append(sg.Scores, scheme.Score{ComponentID: c.ID, StudentID: studentID, Score: score})
+			}
+		}
+
+		sg.Total = gradebook.Total(formula, components, scores[studentID])
+
+		if len(components) > 0 {
+			if grade, err := gradebook.Suggest(sg.Total, rating, scale, rules); err == nil {
+				sg.SuggestedGrade = &grade
+			}
+		}
+
+		book.Students = append(book.Students, sg)
+	}
+
+	return book, nil
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/buildinfo"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/go-chi/render"
+)
+
+// Live answers 200 as long as the process serves requests
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+type ReadyChecker interface {
+	Ready() bool
+}
+
+type DatabaseChecker interface {
+	Ping(context.Context) error
+	MigrationVersion(context.Context) (int64, bool, error)
+}
+
+type ReadyResponse struct {
+	resp.Responce
+	Checks map[string]string `json:"checks"`
+}
+
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+)
+
+// Ready answers 503 while the server is starting or draining, the database
+// is unreachable or its migrations are behind schemaVersion or dirty
+func Ready(log *slog.Logger, state ReadyChecker, s DatabaseChecker, schemaVersion int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.health.Ready"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		checks := map[string]string{
+			"server":     checkOK,
+			"database":   checkOK,
+			"migrations": checkOK,
+		}
+		ready := true
+
+		if !state.Ready() {
+			checks["server"] = "draining"
+			ready = false
+		}
+
+		if err := s.Ping(r.Context()); err != nil {
+			log.Error("database is unreachable", sl.Err(err))
+			checks["database"] = checkFailed
+			checks["migrations"] = "unknown"
+			ready = false
+		} else {
+			version, dirty, err := s.MigrationVersion(r.Context())
+			switch {
+			case err != nil:
+				log.Error("failed to get migration version", sl.Err(err))
+				checks["migrations"] = checkFailed
+				ready = false
+			case dirty:
+				log.Error("migration failed halfway", slog.Int64("version", version))
+				checks["migrations"] = "dirty"
+				ready = false
+			case version < schemaVersion:
+				log.Error("database schema is behind", slog.Int64("version", version), slog.Int64("want", schemaVersion))
+				checks["migrations"] = "behind"
+				ready = false
+			}
+		}
+
+		res := ReadyResponse{
+			Responce: resp.OK(),
+			Checks:   checks,
+		}
+		if !ready {
+			res.Responce = resp.Error("not ready")
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+
+		// Response
+		render.JSON(w, r, res)
+	}
+}
+
+type VersionResponse struct {
+	resp.Responce
+	buildinfo.Info
+}
+
+// Version reports the build metadata of the running binary
+func Version() http.HandlerFunc {
+	info := buildinfo.Get()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, VersionResponse{
+			Responce: resp.OK(),
+			Info:     info,
+		})
+	}
+}
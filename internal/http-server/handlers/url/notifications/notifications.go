@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/notify"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/go-chi/render"
+)
+
+type PreferencesGetter interface {
+	NotificationPreferences(context.Context, int64) (scheme.NotificationPreferences, error)
+}
+
+type GetPreferencesResponse struct {
+	resp.Responce
+	scheme.NotificationPreferences
+}
+
+// GetPreferences returns the notification preferences of the current user
+func GetPreferences(url string, log *slog.Logger, s PreferencesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.notifications.GetPreferences"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		prefs, err := s.NotificationPreferences(r.Context(), userAuthData.ID)
+		if err != nil {
+			log.Error("failed to get notification preferences", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get notification preferences"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetPreferencesResponse{
+			Responce:                resp.OK(),
+			NotificationPreferences: prefs,
+		})
+	}
+}
+
+type PreferencesSetter interface {
+	SetNotificationPreferences(context.Context, int64, scheme.NotificationPreferences) error
+}
+
+type SetPreferencesResponse struct {
+	resp.Responce
+}
+
+// SetPreferences replaces the notification preferences of the current user
+func SetPreferences(url string, log *slog.Logger, s PreferencesSetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.notifications.SetPreferences"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.NotificationPreferences
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Language == "" {
+			req.Language = scheme.LangRU
+		}
+
+		if !slices.Contains(notify.Languages(), req.Language) {
+			log.Info("unknown language", slog.String("language", req.Language))
+			render.JSON(w, r, resp.Error("unknown language"))
+			return
+		}
+
+		for _, kind := range req.Disabled {
+			if !slices.Contains(notify.Kinds(), kind) {
+				log.Info("unknown notification kind", slog.String("kind", kind))
+				render.JSON(w, r, resp.Error("unknown notification kind"))
+				return
+			}
+		}
+
+		if err := s.SetNotificationPreferences(r.Context(), userAuthData.ID, req); err != nil {
+			log.Error("failed to set notification preferences", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set notification preferences"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetPreferencesResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("notification preferences changed", slog.String("language", req.Language))
+	}
+}
@@ -0,0 +1,455 @@
+package periods
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/calendar"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type PeriodsGetter interface {
+	AcademicYears(context.Context) (scheme.AcademicYears, error)
+}
+
+type GetPeriodsResponse struct {
+	resp.Responce
+	scheme.AcademicYears
+}
+
+func Get(url string, log *slog.Logger, s PeriodsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		years, err := s.AcademicYears(r.Context())
+		if err != nil {
+			log.Error("failed to get academic years", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get academic years"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetPeriodsResponse{
+			Responce:      resp.OK(),
+			AcademicYears: years,
+		})
+	}
+}
+
+type AcademicYearSaver interface {
+	SaveAcademicYear(context.Context, *scheme.AcademicYear) (int64, error)
+}
+
+type CreateAcademicYearResponse struct {
+	AcademicYearID int64 `json:"academic_year_id"`
+	resp.Responce
+}
+
+func Create(url string, log *slog.Logger, s AcademicYearSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.Create"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, periods are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.AcademicYear
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Name == "" || !req.StartDate.Before(req.EndDate) {
+			log.Info("invalid academic year")
+			render.JSON(w, r, resp.Error("invalid academic year"))
+			return
+		}
+
+		id, err := s.SaveAcademicYear(r.Context(), &req)
+		if err != nil {
+			log.Error("failed to save academic year", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save academic year"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateAcademicYearResponse{
+			Responce:       resp.OK(),
+			AcademicYearID: id,
+		})
+
+		log.Info("academic year created", slog.Int64("academic_year_id", id))
+	}
+}
+
+type CurrentSemesterSetter interface {
+	SetCurrentSemester(context.Context, int64) error
+}
+
+type SetCurrentRequest struct {
+	SemesterID int64 `json:"semester_id"`
+}
+
+type SetCurrentResponse struct {
+	resp.Responce
+}
+
+func SetCurrent(url string, log *slog.Logger, s CurrentSemesterSetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.SetCurrent"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, periods are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req SetCurrentRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		err = s.SetCurrentSemester(r.Context(), req.SemesterID)
+		if errors.Is(err, store.ErrPeriodNotFound) {
+			log.Info("semester not found", slog.Int64("semester_id", req.SemesterID))
+			render.JSON(w, r, resp.Error("semester not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to set current semester", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to set current semester"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SetCurrentResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("current semester changed", slog.Int64("semester_id", req.SemesterID))
+	}
+}
+
+type AcademicYearArchiver interface {
+	ArchiveAcademicYear(context.Context, int64, bool) error
+}
+
+type ArchiveResponse struct {
+	resp.Responce
+}
+
+// Archive makes the academic year read-only (archived = true) or writable again (archived = false)
+func Archive(url string, archived bool, log *slog.Logger, s AcademicYearArchiver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.Archive"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, periods are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get yearID from URL
+		yearID, err := strconv.ParseInt(chi.URLParam(r, "yearID"), 10, 64)
+		if err != nil {
+			log.Info("unknown yearID")
+			render.JSON(w, r, resp.Error("academic year not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("academic_year_id", yearID),
+		)
+
+		err = s.ArchiveAcademicYear(r.Context(), yearID, archived)
+		if errors.Is(err, store.ErrPeriodNotFound) {
+			log.Info("academic year not found")
+			render.JSON(w, r, resp.Error("academic year not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to archive academic year", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to archive academic year"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, ArchiveResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("academic year archive state changed", slog.Bool("archived", archived))
+	}
+}
+
+type EventsGetter interface {
+	AcademicEvents(context.Context, int64, string) (scheme.AcademicEvents, error)
+}
+
+type GetEventsResponse struct {
+	resp.Responce
+	scheme.AcademicEvents
+}
+
+// GetEvents lists the calendar of the academic year, optionally of one kind
+// given by the kind query parameter. Year 0 lists the whole calendar.
+func GetEvents(url string, log *slog.Logger, s EventsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.GetEvents"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get yearID from URL
+		yearID, err := strconv.ParseInt(chi.URLParam(r, "yearID"), 10, 64)
+		if err != nil {
+			log.Info("unknown yearID")
+			render.JSON(w, r, resp.Error("academic year not found"))
+			return
+		}
+
+		kind := r.URL.Query().Get("kind")
+		if kind != "" && !calendar.ValidKind(kind) {
+			log.Info("unknown event kind", slog.String("kind", kind))
+			render.JSON(w, r, resp.Error("unknown event kind"))
+			return
+		}
+
+		events, err := s.AcademicEvents(r.Context(), yearID, kind)
+		if err != nil {
+			log.Error("failed to get academic events", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get academic events"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetEventsResponse{
+			Responce:       resp.OK(),
+			AcademicEvents: events,
+		})
+	}
+}
+
+// Publisher sends live updates to the connected users
+type Publisher interface {
+	Publish(string, any, ...int64)
+}
+
+type EventSaver interface {
+	SaveAcademicEvent(context.Context, *scheme.AcademicEvent) (int64, error)
+	YearStudents(context.Context, int64) ([]int64, error)
+}
+
+type CreateEventResponse struct {
+	EventID int64 `json:"event_id"`
+	resp.Responce
+}
+
+// CreateEvent adds an event to the calendar of the academic year.
+// Year 0 adds an event not bound to any academic year.
+func CreateEvent(url string, log *slog.Logger, s EventSaver, p Publisher, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.CreateEvent"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the institute calendar is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get yearID from URL
+		yearID, err := strconv.ParseInt(chi.URLParam(r, "yearID"), 10, 64)
+		if err != nil {
+			log.Info("unknown yearID")
+			render.JSON(w, r, resp.Error("academic year not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("academic_year_id", yearID),
+		)
+
+		var req scheme.AcademicEvent
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		req.AcademicYearID = yearID
+
+		if req.DateTo.IsZero() {
+			req.DateTo = req.DateFrom
+		}
+
+		if !calendar.ValidKind(req.Kind) || req.Name == "" || req.DateFrom.IsZero() || req.DateTo.Before(req.DateFrom) {
+			log.Info("invalid academic event")
+			render.JSON(w, r, resp.Error("invalid academic event"))
+			return
+		}
+
+		id, err := s.SaveAcademicEvent(r.Context(), &req)
+		if errors.Is(err, store.ErrPeriodNotFound) {
+			log.Info("academic year not found")
+			render.JSON(w, r, resp.Error("academic year not found"))
+			return
+		}
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("academic year is archived")
+			render.JSON(w, r, resp.Error("academic year is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save academic event", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save academic event"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateEventResponse{
+			Responce: resp.OK(),
+			EventID:  id,
+		})
+
+		log.Info("academic event created", slog.Int64("event_id", id), slog.String("kind", req.Kind))
+
+		// Exam sessions and registration windows open exam slots for the students of the year
+		if req.Kind == scheme.EventExamSession || req.Kind == scheme.EventRegistration {
+			students, err := s.YearStudents(r.Context(), yearID)
+			if err != nil {
+				log.Error("failed to get students of the year", sl.Err(err))
+				return
+			}
+
+			if len(students) > 0 {
+				req.ID = id
+				p.Publish(scheme.LiveExamSlotOpened, req, students...)
+			}
+		}
+	}
+}
+
+type EventDeleter interface {
+	DeleteAcademicEvent(context.Context, int64) error
+}
+
+type DeleteEventResponse struct {
+	resp.Responce
+}
+
+func DeleteEvent(url string, log *slog.Logger, s EventDeleter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.periods.DeleteEvent"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the institute calendar is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get eventID from URL
+		id, err := strconv.ParseInt(chi.URLParam(r, "eventID"), 10, 64)
+		if err != nil {
+			log.Info("unknown eventID")
+			render.JSON(w, r, resp.Error("academic event not found"))
+			return
+		}
+
+		err = s.DeleteAcademicEvent(r.Context(), id)
+		if errors.Is(err, store.ErrEventNotFound) {
+			log.Info("academic event not found", slog.Int64("event_id", id))
+			render.JSON(w, r, resp.Error("academic event not found"))
+			return
+		}
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("academic year is archived", slog.Int64("event_id", id))
+			render.JSON(w, r, resp.Error("academic year is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete academic event", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to delete academic event"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, DeleteEventResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("academic event deleted", slog.Int64("event_id", id))
+	}
+}
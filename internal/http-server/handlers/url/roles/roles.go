@@ -0,0 +1,300 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var validRoles = map[string]bool{
+	"admin":   true,
+	"teacher": true,
+	"student": true,
+}
+
+// UnitResolver locates users and role scopes in the org hierarchy for scoped admins
+type UnitResolver interface {
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+// permitsRole checks that the admin may manage the role of the user.
+// Admin roles are checked against the unit they are scoped to,
+// other roles against the unit the user belongs to.
+func permitsRole(ctx context.Context, url string, key *models.Key, s UnitResolver, ac *policy.AccessControl, userID int64, role scheme.UserRole) (bool, error) {
+	unitID := role.UnitID
+	if role.Role != "admin" {
+		var err error
+		unitID, err = s.UnitOf(ctx, "users", userID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	path, err := s.UnitPath(ctx, unitID)
+	if err != nil {
+		return false, err
+	}
+
+	return ac.Permits(url, key, path), nil
+}
+
+func userIDFromURL(r *http.Request) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+}
+
+type RolesGetter interface {
+	RoleList(context.Context, int64) ([]scheme.UserRole, error)
+	UnitResolver
+}
+
+type GetRolesResponse struct {
+	resp.Responce
+	scheme.UserRoles
+}
+
+func Get(url string, log *slog.Logger, s RolesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.roles.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := userIDFromURL(r)
+		if err != nil {
+			log.Info("unknown userID")
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("target_user_id", userID),
+		)
+
+		// Scope check, the roles of the user are read in the unit of the user
+		ok, err := permitsRole(r.Context(), url, userAuthData, s, ac, userID, scheme.UserRole{})
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		roles, err := s.RoleList(r.Context(), userID)
+		if err != nil {
+			log.Error("failed to get roles", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get roles"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetRolesResponse{
+			Responce:  resp.OK(),
+			UserRoles: scheme.UserRoles{Roles: roles},
+		})
+	}
+}
+
+type RoleAdder interface {
+	AddUserRole(context.Context, int64, scheme.UserRole) error
+	UnitResolver
+}
+
+type AddRoleResponse struct {
+	resp.Responce
+}
+
+func Add(url string, log *slog.Logger, s RoleAdder, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.roles.Add"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := userIDFromURL(r)
+		if err != nil {
+			log.Info("unknown userID")
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("target_user_id", userID),
+		)
+
+		var req scheme.UserRole
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if !validRoles[req.Role] || (req.Role != "admin" && req.UnitID != 0) {
+			log.Info("invalid role", slog.String("role", req.Role))
+			render.JSON(w, r, resp.Error("invalid role"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsRole(r.Context(), url, userAuthData, s, ac, userID, req)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("unit not found", slog.Int64("unit_id", req.UnitID))
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = s.AddUserRole(r.Context(), userID, req)
+		if errors.Is(err, store.ErrUserNotFound) {
+			log.Info("user not found")
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to add role", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to add role"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, AddRoleResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("role added", slog.String("role", req.Role), slog.Int64("unit_id", req.UnitID))
+	}
+}
+
+type RoleRemover interface {
+	RemoveUserRole(context.Context, int64, scheme.UserRole) error
+	UnitResolver
+}
+
+type RemoveRoleResponse struct {
+	resp.Responce
+}
+
+func Remove(url string, log *slog.Logger, s RoleRemover, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.roles.Remove"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := userIDFromURL(r)
+		if err != nil {
+			log.Info("unknown userID")
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("target_user_id", userID),
+		)
+
+		var req scheme.UserRole
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsRole(r.Context(), url, userAuthData, s, ac, userID, req)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("unit not found", slog.Int64("unit_id", req.UnitID))
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("user not found"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = s.RemoveUserRole(r.Context(), userID, req)
+		if errors.Is(err, store.ErrRoleNotFound) {
+			log.Info("role not found", slog.String("role", req.Role))
+			render.JSON(w, r, resp.Error("role not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to remove role", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to remove role"))
+			return
+		}
+
+		// The cached roles of the admin no longer hold
+		if userID == userAuthData.ID {
+			auth.ClearRoleCookie(w)
+		}
+
+		// Response
+		render.JSON(w, r, RemoveRoleResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("role removed", slog.String("role", req.Role), slog.Int64("unit_id", req.UnitID))
+	}
+}
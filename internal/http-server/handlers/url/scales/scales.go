@@ -0,0 +1,170 @@
+package scales
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/go-chi/render"
+)
+
+type ScalesGetter interface {
+	Scales(context.Context) (scheme.Scales, error)
+}
+
+type GetScalesResponse struct {
+	resp.Responce
+	scheme.Scales
+}
+
+func Get(url string, log *slog.Logger, s ScalesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.scales.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		scales, err := s.Scales(r.Context())
+		if err != nil {
+			log.Error("failed to get scales", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get scales"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetScalesResponse{
+			Responce: resp.OK(),
+			Scales:   scales,
+		})
+	}
+}
+
+type ScaleSaver interface {
+	SaveScale(context.Context, *scheme.Scale) (int64, error)
+}
+
+type CreateScaleResponse struct {
+	ScaleID int64 `json:"scale_id"`
+	resp.Responce
+}
+
+func Create(url string, log *slog.Logger, s ScaleSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.scales.Create"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, scales are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Scale
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Code == "" || req.Name == "" || req.Min > req.Pass || req.Pass > req.Max {
+			log.Info("invalid scale")
+			render.JSON(w, r, resp.Error("invalid scale"))
+			return
+		}
+
+		id, err := s.SaveScale(r.Context(), &req)
+		if err != nil {
+			log.Error("failed to save scale", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save scale"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateScaleResponse{
+			Responce: resp.OK(),
+			ScaleID:  id,
+		})
+
+		log.Info("scale created", slog.Int64("scale_id", id))
+	}
+}
+
+type ConversionsSaver interface {
+	SaveConversions(context.Context, []scheme.Conversion) error
+}
+
+type SaveConversionsResponse struct {
+	resp.Responce
+}
+
+// SaveConversions replaces the conversion rules of every scale pair in the request
+func SaveConversions(url string, log *slog.Logger, s ConversionsSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.scales.SaveConversions"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, scales are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Conversions
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		for _, rule := range req.Conversions {
+			if rule.FromMin > rule.FromMax || rule.FromScaleID == rule.ToScaleID {
+				log.Info("invalid conversion rule")
+				render.JSON(w, r, resp.Error("invalid conversion rule"))
+				return
+			}
+		}
+
+		err = s.SaveConversions(r.Context(), req.Conversions)
+		if err != nil {
+			log.Error("failed to save conversions", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save conversions"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SaveConversionsResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("scale conversions saved")
+	}
+}
@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/bus"
+	"github.com/arxonic/journal/internal/services/policy"
+)
+
+const (
+	// Comments keep proxies from closing an idle stream
+	heartbeat = 25 * time.Second
+	// Reconnection delay suggested to the browser, in milliseconds
+	retryMillis = 3000
+)
+
+type Subscriber interface {
+	Subscribe(int64, string) ([]bus.Event, bool, <-chan bus.Event, func())
+}
+
+// Get streams the live updates of the current user as server-sent events.
+// A reconnecting client resumes with the Last-Event-ID header.
+func Get(url string, log *slog.Logger, b Subscriber, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.stream.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+
+		// The stream outlives the write timeout of the server
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Debug("failed to clear write deadline", sl.Err(err))
+		}
+
+		replay, resync, events, cancel := b.Subscribe(userAuthData.ID, r.Header.Get("Last-Event-ID"))
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+
+		if resync {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", scheme.LiveResync)
+		}
+
+		for _, e := range replay {
+			if err := write(w, e); err != nil {
+				log.Error("failed to write event", sl.Err(err))
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			log.Error("streaming is not supported", sl.Err(err))
+			return
+		}
+
+		log.Info("stream opened", slog.Int("replayed", len(replay)), slog.Bool("resync", resync))
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Info("stream closed")
+				return
+			case e, ok := <-events:
+				if !ok {
+					log.Info("stream dropped, the client is too slow or the server is stopping")
+					return
+				}
+				if err := write(w, e); err != nil {
+					log.Error("failed to write event", sl.Err(err))
+					return
+				}
+			case <-ticker.C:
+				if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func write(w io.Writer, e bus.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data)
+	return err
+}
@@ -0,0 +1,418 @@
+package timetable
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/api/period"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/arxonic/journal/internal/services/timetable"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// UnitResolver locates courses in the org hierarchy for scoped admins
+type UnitResolver interface {
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+// permitsCourse checks that the course lies within the admin's scope
+func permitsCourse(ctx context.Context, url string, key *models.Key, s UnitResolver, ac *policy.AccessControl, courseID int64) (bool, error) {
+	unitID, err := s.UnitOf(ctx, "courses", courseID)
+	if err != nil {
+		return false, err
+	}
+
+	path, err := s.UnitPath(ctx, unitID)
+	if err != nil {
+		return false, err
+	}
+
+	return ac.Permits(url, key, path), nil
+}
+
+type RoomsGetter interface {
+	Rooms(context.Context) (scheme.Rooms, error)
+}
+
+type GetRoomsResponse struct {
+	resp.Responce
+	scheme.Rooms
+}
+
+func GetRooms(url string, log *slog.Logger, s RoomsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.timetable.GetRooms"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rooms, err := s.Rooms(r.Context())
+		if err != nil {
+			log.Error("failed to get rooms", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get rooms"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetRoomsResponse{
+			Responce: resp.OK(),
+			Rooms:    rooms,
+		})
+	}
+}
+
+type RoomSaver interface {
+	SaveRoom(context.Context, *scheme.Room) (int64, error)
+}
+
+type CreateRoomResponse struct {
+	RoomID int64 `json:"room_id"`
+	resp.Responce
+}
+
+func CreateRoom(url string, log *slog.Logger, s RoomSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.timetable.CreateRoom"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, the room catalogue is institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Room
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.Name == "" || req.Capacity <= 0 {
+			log.Info("invalid room")
+			render.JSON(w, r, resp.Error("invalid room"))
+			return
+		}
+
+		id, err := s.SaveRoom(r.Context(), &req)
+		if err != nil {
+			log.Error("failed to save room", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save room"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateRoomResponse{
+			Responce: resp.OK(),
+			RoomID:   id,
+		})
+
+		log.Info("room created", slog.Int64("room_id", id))
+	}
+}
+
+type LessonSaver interface {
+	Assignment(context.Context, int64) (scheme.Assignment, error)
+	Course(context.Context, int64) (scheme.Course, error)
+	Room(context.Context, int64) (scheme.Room, error)
+	SaveLesson(context.Context, *scheme.Lesson, scheme.Period, func([]scheme.Lesson, int) []scheme.LessonConflict) (int64, []scheme.LessonConflict, error)
+	UnitResolver
+}
+
+type CreateLessonResponse struct {
+	LessonID  int64                   `json:"lesson_id,omitempty"`
+	Conflicts []scheme.LessonConflict `json:"conflicts,omitempty"`
+	resp.Responce
+}
+
+// CreateLesson puts the assignment into the timetable unless it double-books
+// the teacher, the group or the room, the conflicts are returned otherwise
+func CreateLesson(url string, log *slog.Logger, s LessonSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.timetable.CreateLesson"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Lesson
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := timetable.Validate(&req); err != nil {
+			log.Info("invalid lesson", sl.Err(err))
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		assignment, err := s.Assignment(r.Context(), req.AssignmentID)
+		if err != nil {
+			log.Info("assignment not found", sl.Err(err))
+			render.JSON(w, r, resp.Error("assignment not found"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsCourse(r.Context(), url, userAuthData, s, ac, assignment.CourseID)
+		if err != nil {
+			log.Error("failed to check scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save lesson"))
+			return
+		}
+		if !ok {
+			log.Error("course is out of scope", slog.Int64("course_id", assignment.CourseID))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		room, err := s.Room(r.Context(), req.RoomID)
+		if errors.Is(err, store.ErrRoomNotFound) {
+			log.Info("room not found", slog.Int64("room_id", req.RoomID))
+			render.JSON(w, r, resp.Error("room not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get room", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save lesson"))
+			return
+		}
+
+		course, err := s.Course(r.Context(), assignment.CourseID)
+		if err != nil {
+			log.Error("failed to get course", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save lesson"))
+			return
+		}
+
+		req.CourseID = assignment.CourseID
+		req.DisciplineID = assignment.DisciplineID
+		req.TeacherID = assignment.TeacherID
+
+		// The conflicts are checked within the transaction of the insert
+		period := scheme.Period{
+			AcademicYearID: course.AcademicYearID,
+			SemesterID:     assignment.SemesterID,
+		}
+		check := func(existing []scheme.Lesson, size int) []scheme.LessonConflict {
+			return timetable.Conflicts(req, existing, room, size)
+		}
+
+		id, conflicts, err := s.SaveLesson(r.Context(), &req, period, check)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", assignment.ID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save lesson", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save lesson"))
+			return
+		}
+		if len(conflicts) > 0 {
+			log.Info("lesson conflicts", slog.Int("conflicts", len(conflicts)))
+			render.JSON(w, r, CreateLessonResponse{
+				Responce:  resp.Error("lesson conflicts with the timetable"),
+				Conflicts: conflicts,
+			})
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateLessonResponse{
+			Responce: resp.OK(),
+			LessonID: id,
+		})
+
+		log.Info("lesson created", slog.Int64("lesson_id", id))
+	}
+}
+
+type LessonDeleter interface {
+	Lesson(context.Context, int64) (scheme.Lesson, error)
+	DeleteLesson(context.Context, int64) error
+	UnitResolver
+}
+
+type DeleteLessonResponse struct {
+	resp.Responce
+}
+
+func DeleteLesson(url string, log *slog.Logger, s LessonDeleter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.timetable.DeleteLesson"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get lessonID from URL
+		lessonID, err := strconv.ParseInt(chi.URLParam(r, "lessonID"), 10, 64)
+		if err != nil {
+			log.Info("unknown lessonID")
+			render.JSON(w, r, resp.Error("lesson not found"))
+			return
+		}
+
+		lesson, err := s.Lesson(r.Context(), lessonID)
+		if errors.Is(err, store.ErrLessonNotFound) {
+			log.Info("lesson not found", slog.Int64("lesson_id", lessonID))
+			render.JSON(w, r, resp.Error("lesson not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get lesson", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to delete lesson"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsCourse(r.Context(), url, userAuthData, s, ac, lesson.CourseID)
+		if err != nil || !ok {
+			log.Error("course is out of scope", slog.Int64("course_id", lesson.CourseID))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = s.DeleteLesson(r.Context(), lessonID)
+		if errors.Is(err, store.ErrPeriodArchived) {
+			log.Info("assignment is archived", slog.Int64("assignment_id", lesson.AssignmentID))
+			render.JSON(w, r, resp.Error("assignment is archived"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete lesson", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to delete lesson"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, DeleteLessonResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("lesson deleted", slog.Int64("lesson_id", lessonID))
+	}
+}
+
+type TimetableGetter interface {
+	TeacherTimetable(context.Context, int64, scheme.Period) (scheme.Timetable, error)
+	StudentTimetable(context.Context, int64, scheme.Period) (scheme.Timetable, error)
+	period.CurrentPeriodGetter
+}
+
+type GetResponse struct {
+	resp.Responce
+	scheme.Timetable
+}
+
+// Get returns the personal weekly timetable, ?week=odd|even leaves the lessons of that week only
+func Get(url string, log *slog.Logger, s TimetableGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.timetable.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get Period
+		p, err := period.FromRequest(r, s)
+		if err != nil {
+			log.Error("failed to get period", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid period"))
+			return
+		}
+
+		week := r.URL.Query().Get("week")
+		if week != "" && week != scheme.WeekOdd && week != scheme.WeekEven {
+			log.Info("invalid week", slog.String("week", week))
+			render.JSON(w, r, resp.Error("week must be odd or even"))
+			return
+		}
+
+		var tt scheme.Timetable
+
+		switch ac.Role(url, userAuthData) {
+		case "teacher":
+			tt, err = s.TeacherTimetable(r.Context(), userAuthData.ID, p)
+		case "student":
+			tt, err = s.StudentTimetable(r.Context(), userAuthData.ID, p)
+		}
+		if err != nil {
+			log.Error("failed to get timetable", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get timetable"))
+			return
+		}
+
+		if week != "" {
+			lessons := make([]scheme.Lesson, 0, len(tt.Lessons))
+			for _, l := range tt.Lessons {
+				if l.WeekParity == scheme.WeekEvery || l.WeekParity == week {
+					lessons = append(lessons, l)
+				}
+			}
+			tt.Lessons = lessons
+		}
+
+		// Response
+		render.JSON(w, r, GetResponse{
+			Responce:  resp.OK(),
+			Timetable: tt,
+		})
+	}
+}
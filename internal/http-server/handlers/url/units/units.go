@@ -0,0 +1,264 @@
+package units
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type UnitsGetter interface {
+	Units(context.Context, []int64) (scheme.Units, error)
+}
+
+type GetUnitsResponse struct {
+	resp.Responce
+	scheme.Units
+}
+
+// Get returns the org units visible to the admin: the whole tree or the admin's subtree
+func Get(url string, log *slog.Logger, s UnitsGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.units.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// User Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		units, err := s.Units(r.Context(), ac.Scopes(url, userAuthData))
+		if err != nil {
+			log.Error("failed to get units", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get units"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetUnitsResponse{
+			Responce: resp.OK(),
+			Units:    units,
+		})
+	}
+}
+
+type UnitSaver interface {
+	SaveUnit(context.Context, *scheme.Unit) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+type CreateUnitResponse struct {
+	UnitID int64 `json:"unit_id"`
+	resp.Responce
+}
+
+func Create(url string, log *slog.Logger, s UnitSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.units.Create"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req scheme.Unit
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// Scope check, the new unit is placed under its parent
+		path, err := s.UnitPath(r.Context(), req.ParentID)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("parent unit not found", slog.Int64("parent_id", req.ParentID))
+			render.JSON(w, r, resp.Error("parent unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get parent unit", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save unit"))
+			return
+		}
+
+		if !ac.Permits(url, userAuthData, path) {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := s.SaveUnit(r.Context(), &req)
+		if errors.Is(err, store.ErrInvalidUnit) || errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("invalid unit", slog.String("kind", req.Kind), slog.Int64("parent_id", req.ParentID))
+			render.JSON(w, r, resp.Error("invalid unit"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save unit", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save unit"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateUnitResponse{
+			Responce: resp.OK(),
+			UnitID:   id,
+		})
+
+		log.Info("unit created", slog.Int64("unit_id", id))
+	}
+}
+
+type UnitMembersSaver interface {
+	SaveUnitMembers(context.Context, int64, *scheme.UnitMembers) error
+	UnitOf(context.Context, string, int64) (int64, error)
+	UnitPath(context.Context, int64) ([]int64, error)
+}
+
+type SaveMembersResponse struct {
+	resp.Responce
+}
+
+// SaveMembers moves users, disciplines and courses into the unit.
+// Scoped admins may only move rows that are unassigned or already within their subtree.
+func SaveMembers(url string, log *slog.Logger, s UnitMembersSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.units.SaveMembers"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Contains(url, userAuthData) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get unitID from URL
+		unitID, err := strconv.ParseInt(chi.URLParam(r, "unitID"), 10, 64)
+		if err != nil {
+			log.Info("unknown unitID")
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+
+		log = log.With(
+			slog.Int64("unit_id", unitID),
+		)
+
+		var req scheme.UnitMembers
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// Scope check
+		ok, err := permitsMembers(r.Context(), url, userAuthData, s, ac, unitID, &req)
+		if errors.Is(err, store.ErrUnitNotFound) {
+			log.Info("unit not found")
+			render.JSON(w, r, resp.Error("unit not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check admin scope", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save unit members"))
+			return
+		}
+		if !ok {
+			log.Error("operation outside of admin scope", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err = s.SaveUnitMembers(r.Context(), unitID, &req)
+		if err != nil {
+			log.Error("failed to save unit members", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save unit members"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, SaveMembersResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("unit members saved")
+	}
+}
+
+func permitsMembers(ctx context.Context, url string, key *models.Key, s UnitMembersSaver, ac *policy.AccessControl, unitID int64, members *scheme.UnitMembers) (bool, error) {
+	path, err := s.UnitPath(ctx, unitID)
+	if err != nil {
+		return false, err
+	}
+	if path == nil {
+		return false, store.ErrUnitNotFound
+	}
+
+	if !ac.Permits(url, key, path) {
+		return false, nil
+	}
+
+	if ac.Scopes(url, key) == nil {
+		return true, nil
+	}
+
+	for table, ids := range map[string][]int64{
+		"users":       members.UserIDs,
+		"disciplines": members.DisciplineIDs,
+		"courses":     members.CourseIDs,
+	} {
+		for _, id := range ids {
+			current, err := s.UnitOf(ctx, table, id)
+			if err != nil {
+				return false, err
+			}
+			if current == 0 {
+				continue
+			}
+
+			path, err := s.UnitPath(ctx, current)
+			if err != nil {
+				return false, err
+			}
+
+			if !ac.Permits(url, key, path) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/services/policy"
+	"github.com/arxonic/journal/internal/services/webhooks"
+	store "github.com/arxonic/journal/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultDeliveries = 50
+	maxDeliveries     = 500
+)
+
+type WebhooksGetter interface {
+	Webhooks(context.Context) (scheme.Webhooks, error)
+}
+
+type GetResponse struct {
+	resp.Responce
+	scheme.Webhooks
+}
+
+func Get(url string, log *slog.Logger, s WebhooksGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.webhooks.Get"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, webhooks are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		hooks, err := s.Webhooks(r.Context())
+		if err != nil {
+			log.Error("failed to get webhooks", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get webhooks"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, GetResponse{
+			Responce: resp.OK(),
+			Webhooks: hooks,
+		})
+	}
+}
+
+type WebhookSaver interface {
+	SaveWebhook(context.Context, *scheme.Webhook) (int64, error)
+}
+
+type CreateRequest struct {
+	URL    string   `json:"url"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+type CreateResponse struct {
+	WebhookID int64 `json:"webhook_id"`
+	// The signing key is only shown once
+	Secret string `json:"secret"`
+	resp.Responce
+}
+
+func Create(url string, log *slog.Logger, s WebhookSaver, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.webhooks.Create"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, webhooks are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req CreateRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		u, err := neturl.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Info("invalid webhook url", slog.String("url", req.URL))
+			render.JSON(w, r, resp.Error("invalid webhook url"))
+			return
+		}
+
+		for _, topic := range req.Topics {
+			if !slices.Contains(webhooks.Topics(), topic) {
+				log.Info("unknown topic", slog.String("topic", topic))
+				render.JSON(w, r, resp.Error("unknown topic"))
+				return
+			}
+		}
+
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			log.Error("failed to generate webhook secret", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save webhook"))
+			return
+		}
+
+		id, err := s.SaveWebhook(r.Context(), &scheme.Webhook{
+			URL:    req.URL,
+			Secret: secret,
+			Topics: req.Topics,
+		})
+		if err != nil {
+			log.Error("failed to save webhook", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to save webhook"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, CreateResponse{
+			Responce:  resp.OK(),
+			WebhookID: id,
+			Secret:    secret,
+		})
+
+		log.Info("webhook created", slog.Int64("webhook_id", id), slog.String("url", req.URL))
+	}
+}
+
+type WebhookDeleter interface {
+	DeleteWebhook(context.Context, int64) error
+}
+
+type DeleteResponse struct {
+	resp.Responce
+}
+
+func Delete(url string, log *slog.Logger, s WebhookDeleter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.webhooks.Delete"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, webhooks are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get webhookID from URL
+		id, err := strconv.ParseInt(chi.URLParam(r, "webhookID"), 10, 64)
+		if err != nil {
+			log.Info("unknown webhookID")
+			render.JSON(w, r, resp.Error("webhook not found"))
+			return
+		}
+
+		err = s.DeleteWebhook(r.Context(), id)
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("webhook_id", id))
+			render.JSON(w, r, resp.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete webhook", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to delete webhook"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, DeleteResponse{
+			Responce: resp.OK(),
+		})
+
+		log.Info("webhook deleted", slog.Int64("webhook_id", id))
+	}
+}
+
+type DeliveriesGetter interface {
+	WebhookDeliveries(context.Context, int64, int) (scheme.WebhookDeliveries, error)
+}
+
+type DeliveriesResponse struct {
+	resp.Responce
+	scheme.WebhookDeliveries
+}
+
+// Deliveries is the delivery log of the webhook, newest first. The limit
+// query parameter caps the number of entries.
+func Deliveries(url string, log *slog.Logger, s DeliveriesGetter, ac *policy.AccessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const fn = "http-server.handlers.url.webhooks.Deliveries"
+
+		log := sl.FromContext(r.Context(), log).With(
+			slog.String("fn", fn),
+		)
+
+		// Role check, webhooks are institute-wide
+		userAuthData := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		if !ac.Permits(url, userAuthData, nil) {
+			log.Error("unauthorized operation", sl.Err(policy.ErrUnauthorized))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Get webhookID from URL
+		id, err := strconv.ParseInt(chi.URLParam(r, "webhookID"), 10, 64)
+		if err != nil {
+			log.Info("unknown webhookID")
+			render.JSON(w, r, resp.Error("webhook not found"))
+			return
+		}
+
+		limit := defaultDeliveries
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				log.Info("invalid limit", slog.String("limit", v))
+				render.JSON(w, r, resp.Error("invalid limit"))
+				return
+			}
+			limit = min(limit, maxDeliveries)
+		}
+
+		deliveries, err := s.WebhookDeliveries(r.Context(), id, limit)
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("webhook_id", id))
+			render.JSON(w, r, resp.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get webhook deliveries", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to get webhook deliveries"))
+			return
+		}
+
+		// Response
+		render.JSON(w, r, DeliveriesResponse{
+			Responce:          resp.OK(),
+			WebhookDeliveries: deliveries,
+		})
+	}
+}
@@ -8,12 +8,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/arxonic/journal/internal/domain/models"
-	"github.com/arxonic/journal/internal/storage/sqlite"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/arxonic/journal/internal/lib/tracing"
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var (
@@ -24,81 +29,156 @@ type ContextKey string
 
 const ContextAuthMiddlewareKey ContextKey = "authMiddleware"
 
+// Header a user holding several roles selects the role of the request with
+const ActiveRoleHeader = "X-Active-Role"
+
+// Cookie caching the encrypted roles of the user
+const RoleCookie = "role"
+
+// failureKey holds where Auth reports why it rejected the request
+const failureKey ContextKey = "authFailure"
+
+// TrackFailures returns a context in which Auth reports the metrics.Auth*
+// reason it rejected the request with, failure reads it after the request
+// was served and is empty if the authentication passed or never ran
+func TrackFailures(ctx context.Context) (_ context.Context, failure func() string) {
+	reason := new(string)
+	return context.WithValue(ctx, failureKey, reason), func() string { return *reason }
+}
+
+func reportFailure(ctx context.Context, reason string) {
+	metrics.AuthFailures.WithLabelValues(reason).Inc()
+
+	if p, ok := ctx.Value(failureKey).(*string); ok {
+		*p = reason
+	}
+}
+
+// Storage resolves the roles of the authenticated users
+type Storage interface {
+	UserRoles(ctx context.Context, email string) (models.Key, error)
+	RolesVersion(ctx context.Context, userID int64) (int64, error)
+}
+
 type AuthMiddleware struct {
 	Secret  string
-	Storage *sqlite.Storage
+	Storage Storage
+
+	versions *versionCache
 }
 
-func New(secret string, storage *sqlite.Storage) *AuthMiddleware {
+func New(secret string, storage Storage) *AuthMiddleware {
 	return &AuthMiddleware{
-		Secret:  secret,
-		Storage: storage,
+		Secret:   secret,
+		Storage:  storage,
+		versions: newVersionCache(rolesVersionTTL),
 	}
 }
 
 func (m *AuthMiddleware) Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// get jwt string from header
-		jwtString := getJWTFromHeader(r)
-		if jwtString == "" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		key, reason := m.authenticate(w, r)
+		switch reason {
+		case "":
+		case metrics.AuthRoleDenied:
+			reportFailure(r.Context(), reason)
+			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
-		}
-
-		// get jwt from jwt string
-		token, err := jwt.Parse(jwtString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(m.Secret), nil
-		})
-		if err != nil {
+		default:
+			reportFailure(r.Context(), reason)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		email := ""
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			email = claims["email"].(string)
-		} else {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		ctx := context.WithValue(r.Context(), ContextAuthMiddlewareKey, &key)
+
+		// The user is part of every log entry of the request
+		if log := sl.FromContext(ctx, nil); log != nil {
+			ctx = sl.WithLogger(ctx, log.With(slog.Int64("user_id", key.ID)))
 		}
 
-		var key models.Key
-
-		encryptedRole := getRoleFromCookie(r)
-
-		if encryptedRole == "" {
-			key, err = setRoleToCookie(w, email, m)
-			if err != nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			}
-		} else {
-			key, err = checkRoleFromCookie(email, encryptedRole, m.Secret)
-			if err != nil {
-				key, err = setRoleToCookie(w, email, m)
-				if err != nil {
-					http.Error(w, "Unauthorized", http.StatusUnauthorized)
-					return
-				}
-			}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate resolves the key of the request, reason is one of the
+// metrics.Auth* reasons if it is rejected
+func (m *AuthMiddleware) authenticate(w http.ResponseWriter, r *http.Request) (key models.Key, reason string) {
+	ctx, span := tracing.Start(r.Context(), "auth.Authenticate")
+	defer func() {
+		if reason != "" {
+			span.SetStatus(codes.Error, reason)
 		}
+		span.End()
+	}()
 
-		ctx := context.WithValue(r.Context(), ContextAuthMiddlewareKey, &key)
+	// get jwt string from header
+	jwtString := getJWTFromHeader(r)
+	if jwtString == "" {
+		return models.Key{}, metrics.AuthNoToken
+	}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+	// get jwt from jwt string
+	token, err := jwt.Parse(jwtString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.Secret), nil
 	})
+	if err != nil {
+		return models.Key{}, metrics.AuthInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return models.Key{}, metrics.AuthInvalidToken
+	}
+
+	// Signed tokens without the email are rejected the same way
+	email, ok := claims["email"].(string)
+	if !ok || email == "" {
+		return models.Key{}, metrics.AuthInvalidToken
+	}
+
+	encryptedRole := getRoleFromCookie(r)
+
+	if encryptedRole == "" {
+		err = ErrOldCookie
+	} else {
+		key, err = checkRoleFromCookie(ctx, email, encryptedRole, m)
+	}
+	if err != nil {
+		key, err = setRoleToCookie(ctx, w, email, m)
+		if err != nil {
+			ClearRoleCookie(w)
+			return models.Key{}, metrics.AuthUnknownUser
+		}
+	}
+
+	// Active role
+	if active := r.Header.Get(ActiveRoleHeader); active != "" {
+		if !key.Has(active) {
+			return models.Key{}, metrics.AuthRoleDenied
+		}
+		key.Active = active
+	}
+
+	span.SetAttributes(attribute.Int64("user_id", key.ID))
+
+	return key, ""
 }
 
-func checkRoleFromCookie(email, encryptedRole, secret string) (models.Key, error) {
+// checkRoleFromCookie decodes the cached key, it is old once the roles of the
+// user changed after the cookie was issued. The change is seen within
+// rolesVersionTTL, see versionCache.
+func checkRoleFromCookie(ctx context.Context, email, encryptedRole string, m *AuthMiddleware) (models.Key, error) {
 	var key models.Key
 	encDecoded, err := base64.StdEncoding.DecodeString(encryptedRole)
 	if err != nil {
 		return key, err
 	}
 
-	dec, err := decrypt([]byte(encDecoded), []byte(secret))
+	dec, err := decrypt([]byte(encDecoded), []byte(m.Secret))
 	if err != nil {
 		return key, err
 	}
@@ -108,18 +188,34 @@ func checkRoleFromCookie(email, encryptedRole, secret string) (models.Key, error
 		return key, err
 	}
 
-	if key.Email != email {
+	// Cookies issued before multi-role support carry no role set
+	if key.Email != email || key.Roles == nil {
+		return key, ErrOldCookie
+	}
+
+	version, err := m.versions.get(ctx, m.Storage, key.ID)
+	if err != nil {
+		return key, err
+	}
+	if version != key.RolesVersion {
 		return key, ErrOldCookie
 	}
 
 	return key, nil
 }
 
-func setRoleToCookie(w http.ResponseWriter, email string, m *AuthMiddleware) (models.Key, error) {
-	key, err := m.Storage.UserRole(email)
+func setRoleToCookie(ctx context.Context, w http.ResponseWriter, email string, m *AuthMiddleware) (models.Key, error) {
+	key, err := m.Storage.UserRoles(ctx, email)
 	if err != nil {
 		return models.Key{}, err
 	}
+	m.versions.set(key.ID, key.RolesVersion)
+
+	// Nothing to cache for a user whose roles were all revoked
+	if len(key.Roles) == 0 {
+		ClearRoleCookie(w)
+		return key, nil
+	}
 
 	value, err := json.Marshal(key)
 	if err != nil {
@@ -134,7 +230,7 @@ func setRoleToCookie(w http.ResponseWriter, email string, m *AuthMiddleware) (mo
 	encEncoded := base64.StdEncoding.EncodeToString(enc)
 
 	cookie := http.Cookie{
-		Name:     "role",
+		Name:     RoleCookie,
 		Value:    encEncoded,
 		Path:     "/",
 		MaxAge:   9999,
@@ -147,6 +243,17 @@ func setRoleToCookie(w http.ResponseWriter, email string, m *AuthMiddleware) (mo
 	return key, nil
 }
 
+// ClearRoleCookie drops the cached roles, the next request reads them again
+func ClearRoleCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     RoleCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 func getJWTFromHeader(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader != "" {
@@ -160,7 +267,7 @@ func getJWTFromHeader(r *http.Request) string {
 }
 
 func getRoleFromCookie(r *http.Request) string {
-	cookie, err := r.Cookie("role")
+	cookie, err := r.Cookie(RoleCookie)
 	if err == nil {
 		return cookie.Value
 	}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "passphrasewhichneedstobe32bytes!"
+
+var errUnknownUser = errors.New("user not found")
+
+// fakeStorage knows the users by email
+type fakeStorage map[string]models.Key
+
+func (f fakeStorage) UserRoles(_ context.Context, email string) (models.Key, error) {
+	key, ok := f[email]
+	if !ok {
+		return models.Key{}, errUnknownUser
+	}
+	return key, nil
+}
+
+func (f fakeStorage) RolesVersion(_ context.Context, userID int64) (int64, error) {
+	for _, key := range f {
+		if key.ID == userID {
+			return key.RolesVersion, nil
+		}
+	}
+	return 0, errUnknownUser
+}
+
+var users = fakeStorage{
+	"adm@gmail.com": {ID: 1, Email: "adm@gmail.com", Roles: []models.Role{{Name: "admin"}}},
+	"stu@gmail.com": {ID: 3, Email: "stu@gmail.com", Roles: []models.Role{{Name: "student"}}},
+}
+
+func token(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func TestAuthenticate(t *testing.T) {
+	m := New(testSecret, users)
+
+	tests := []struct {
+		name   string
+		token  string
+		active string
+		reason string
+		userID int64
+	}{
+		{name: "no token", reason: metrics.AuthNoToken},
+		{name: "garbage", token: "garbage", reason: metrics.AuthInvalidToken},
+		{name: "other secret", token: token(t, "another secret", jwt.MapClaims{"email": "adm@gmail.com"}), reason: metrics.AuthInvalidToken},
+		{name: "no email", token: token(t, testSecret, jwt.MapClaims{"sub": "1"}), reason: metrics.AuthInvalidToken},
+		{name: "empty email", token: token(t, testSecret, jwt.MapClaims{"email": ""}), reason: metrics.AuthInvalidToken},
+		{name: "email not a string", token: token(t, testSecret, jwt.MapClaims{"email": 42}), reason: metrics.AuthInvalidToken},
+		{name: "unknown user", token: token(t, testSecret, jwt.MapClaims{"email": "nobody@gmail.com"}), reason: metrics.AuthUnknownUser},
+		{name: "role not held", token: token(t, testSecret, jwt.MapClaims{"email": "stu@gmail.com"}), active: "admin", reason: metrics.AuthRoleDenied},
+		{name: "user", token: token(t, testSecret, jwt.MapClaims{"email": "stu@gmail.com"}), userID: 3},
+		{name: "active role", token: token(t, testSecret, jwt.MapClaims{"email": "adm@gmail.com"}), active: "admin", userID: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/courses", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.active != "" {
+				r.Header.Set(ActiveRoleHeader, tt.active)
+			}
+
+			key, reason := m.authenticate(httptest.NewRecorder(), r)
+			if reason != tt.reason {
+				t.Fatalf("reason = %q, want %q", reason, tt.reason)
+			}
+			if key.ID != tt.userID {
+				t.Fatalf("user = %d, want %d", key.ID, tt.userID)
+			}
+		})
+	}
+}
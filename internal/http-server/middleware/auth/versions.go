@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The roles version of a user is looked up at most once per rolesVersionTTL.
+// It saves the query on every request that the role cookie was meant to
+// save, at the price of a revocation taking up to the TTL to reach the
+// cookies already issued.
+const rolesVersionTTL = 30 * time.Second
+
+// Versions of the users not seen for sweepInterval are dropped
+const sweepInterval = 5 * time.Minute
+
+type cachedVersion struct {
+	version int64
+	expires time.Time
+}
+
+// versionCache keeps the roles versions of the users for the TTL, a nil
+// cache reads every version from the storage
+type versionCache struct {
+	ttl       time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	versions  map[int64]cachedVersion
+	lastSweep time.Time
+}
+
+func newVersionCache(ttl time.Duration) *versionCache {
+	return &versionCache{
+		ttl:       ttl,
+		now:       time.Now,
+		versions:  make(map[int64]cachedVersion),
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the version of the user, from storage once the cached one expired
+func (c *versionCache) get(ctx context.Context, s Storage, userID int64) (int64, error) {
+	if c == nil {
+		return s.RolesVersion(ctx, userID)
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	v, ok := c.versions[userID]
+	c.mu.Unlock()
+
+	if ok && now.Before(v.expires) {
+		return v.version, nil
+	}
+
+	version, err := s.RolesVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	c.set(userID, version)
+	return version, nil
+}
+
+// set caches the version the roles were just read with
+func (c *versionCache) set(userID, version int64) {
+	if c == nil {
+		return
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) >= sweepInterval {
+		for id, v := range c.versions {
+			if !now.Before(v.expires) {
+				delete(c.versions, id)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.versions[userID] = cachedVersion{version: version, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingStorage counts the version lookups
+type countingStorage struct {
+	fakeStorage
+	version int64
+	lookups int
+}
+
+func (c *countingStorage) RolesVersion(context.Context, int64) (int64, error) {
+	c.lookups++
+	return c.version, nil
+}
+
+func TestVersionCache(t *testing.T) {
+	s := &countingStorage{fakeStorage: users, version: 1}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newVersionCache(rolesVersionTTL)
+	c.now = func() time.Time { return now }
+	c.lastSweep = now
+
+	get := func(want int64, lookups int) {
+		t.Helper()
+		v, err := c.get(context.Background(), s, 1)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if v != want || s.lookups != lookups {
+			t.Fatalf("get = %d after %d lookups, want %d after %d", v, s.lookups, want, lookups)
+		}
+	}
+
+	get(1, 1)
+	get(1, 1)
+
+	// A revocation is seen once the TTL is over
+	s.version = 2
+	now = now.Add(rolesVersionTTL - time.Second)
+	get(1, 1)
+	now = now.Add(time.Second)
+	get(2, 2)
+
+	// The version the roles were just read with replaces the cached one
+	c.set(1, 3)
+	get(3, 2)
+
+	// Expired versions are swept
+	now = now.Add(sweepInterval)
+	c.set(2, 1)
+	if _, ok := c.versions[1]; ok {
+		t.Fatal("expired version was kept")
+	}
+}
@@ -0,0 +1,56 @@
+package cors
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/http-server/middleware/csrf"
+	"github.com/arxonic/journal/internal/http-server/middleware/logger"
+	"github.com/arxonic/journal/internal/http-server/versioning"
+	"github.com/arxonic/journal/internal/lib/tracing"
+	chicors "github.com/go-chi/cors"
+)
+
+// Request headers allowed unless configured otherwise
+var defaultHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	auth.ActiveRoleHeader,
+	csrf.HeaderName,
+	"Last-Event-ID",
+	"traceparent",
+	"tracestate",
+}
+
+// New lets the browser apps served from origins call the API. Credentials
+// allow them to send the role cookie; it also needs explicit origins, "*"
+// is not honoured then. Preflight requests are answered here, before the
+// authentication.
+func New(origins, headers []string, credentials bool, maxAge time.Duration) func(next http.Handler) http.Handler {
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+
+	return chicors.Handler(chicors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders: headers,
+		ExposedHeaders: []string{
+			logger.HeaderRequestID,
+			tracing.HeaderTraceID,
+			"Retry-After",
+			versioning.HeaderDeprecation,
+			versioning.HeaderSunset,
+			versioning.HeaderLink,
+		},
+		AllowCredentials: credentials,
+		MaxAge:           int(maxAge.Seconds()),
+	})
+}
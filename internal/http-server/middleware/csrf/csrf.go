@@ -0,0 +1,111 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"slices"
+
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/go-chi/render"
+)
+
+const (
+	// Cookie with the token, readable by the scripts of the page
+	CookieName = "csrf_token"
+	// Header the page echoes the token in on state-changing requests
+	HeaderName = "X-CSRF-Token"
+
+	tokenBytes = 32
+)
+
+// New protects the state-changing requests of browser sessions with the
+// double-submit cookie pattern: the token cookie is issued with any response
+// and must come back in the X-CSRF-Token header. A foreign Origin is refused
+// as well. The check only applies to requests carrying sessionCookie, clients
+// that send nothing but the Authorization header are not exposed to CSRF.
+func New(log *slog.Logger, sessionCookie string, trustedOrigins []string, secure bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var token string
+			if c, err := r.Cookie(CookieName); err == nil && len(c.Value) == 2*tokenBytes {
+				token = c.Value
+			} else {
+				token, err = newToken()
+				if err != nil {
+					sl.FromContext(r.Context(), log).Error("failed to generate csrf token", sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("internal error"))
+					return
+				}
+
+				http.SetCookie(w, &http.Cookie{
+					Name:     CookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+				// A fresh token cannot have been echoed
+				token = ""
+			}
+
+			if safe(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if _, err := r.Cookie(sessionCookie); err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := sl.FromContext(r.Context(), log)
+
+			if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r) && !slices.Contains(trustedOrigins, origin) {
+				log.Warn("csrf: foreign origin", slog.String("origin", origin))
+				forbid(w, r)
+				return
+			}
+
+			header := r.Header.Get(HeaderName)
+			if token == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+				log.Warn("csrf: token mismatch")
+				forbid(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := neturl.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func forbid(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusForbidden)
+	render.JSON(w, r, resp.Error("invalid csrf token"))
+}
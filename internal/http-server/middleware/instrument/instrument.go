@@ -0,0 +1,35 @@
+package instrument
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Requests not matching any route, or rejected before the routing has
+// finished, share one label to bound the cardinality
+const unmatched = "unmatched"
+
+// Metrics counts and times requests by chi route pattern,
+// e.g. "/courses/{courseID}" instead of the raw path
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
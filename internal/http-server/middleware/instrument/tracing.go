@@ -0,0 +1,78 @@
+package instrument
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/arxonic/journal/internal/lib/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing opens the server span of the request, continuing the trace of the
+// caller if it sent a traceparent header. The trace ID is returned in the
+// X-Trace-ID header so that error reports can be matched with the trace.
+// The span carries the route pattern only, the raw path holds secrets on
+// some routes, e.g. the token of a calendar feed.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracing.StartServer(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+		)
+		defer span.End()
+
+		if id := tracing.TraceID(ctx); id != "" {
+			w.Header().Set(tracing.HeaderTraceID, id)
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// Handler opens a span for the handler of the route, next to the one of
+// the authentication
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "handler")
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		span.SetName("handler " + routePattern(r))
+	})
+}
+
+// routePattern is the chi pattern of the matched route, e.g.
+// "/courses/{courseID}" instead of the raw path
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		// A bare mount pattern means no route of the subrouter matched
+		if p := rctx.RoutePattern(); p != "" && !strings.HasSuffix(p, "/*") {
+			return p
+		}
+	}
+	return unmatched
+}
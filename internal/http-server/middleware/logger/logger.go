@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header returning the request ID to the client
+const HeaderRequestID = "X-Request-ID"
+
+// New puts the logger of the request, the root logger with the request and
+// trace IDs, into the context for the handlers and writes an access log
+// entry once the request is served. It goes after middleware.RequestID.
+// The entry holds the chi route pattern instead of the raw path, which
+// carries secrets on some routes, e.g. the token of a calendar feed.
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqID := middleware.GetReqID(r.Context())
+
+			reqLog := log.With(slog.String("request_id", reqID))
+			if traceID := tracing.TraceID(r.Context()); traceID != "" {
+				reqLog = reqLog.With(slog.String(sl.TraceIDKey, traceID))
+			}
+
+			if reqID != "" {
+				w.Header().Set(HeaderRequestID, reqID)
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				level := slog.LevelInfo
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+
+				reqLog.Log(r.Context(), level, "request completed",
+					slog.String("method", r.Method),
+					slog.String("route", routePattern(r)),
+					slog.String("remote_addr", r.RemoteAddr),
+					slog.String("user_agent", r.UserAgent()),
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Duration("duration", time.Since(start)),
+				)
+			}()
+
+			next.ServeHTTP(ww, r.WithContext(sl.WithLogger(r.Context(), reqLog)))
+		})
+	}
+}
+
+// routePattern is the chi pattern of the matched route, empty if none matched
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/http-server/middleware/logger"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	secret   = "passphrasewhichneedstobe32bytes!"
+	users    = 8
+	requests = 200
+)
+
+type storage struct{}
+
+func (storage) UserRoles(_ context.Context, email string) (models.Key, error) {
+	var id int64
+	if _, err := fmt.Sscanf(email, "user%d@gmail.com", &id); err != nil {
+		return models.Key{}, err
+	}
+	return models.Key{ID: id, Email: email, Roles: []models.Role{{Name: "student"}}}, nil
+}
+
+func (storage) RolesVersion(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
+// syncBuffer collects the log output of concurrent requests
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func TestRequestLoggersAreIsolated(t *testing.T) {
+	var out syncBuffer
+	log := slog.New(slog.NewJSONHandler(&out, nil))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(logger.New(log))
+	r.Use(auth.New(secret, storage{}).Auth)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		key := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+		sl.FromContext(r.Context(), log).Info("handled",
+			slog.String("want_request_id", middleware.GetReqID(r.Context())),
+			slog.Int64("want_user_id", key.ID),
+		)
+	})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	tokens := make([]string, users)
+	for i := range tokens {
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": fmt.Sprintf("user%d@gmail.com", i+1),
+		}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		tokens[i] = tok
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Header.Set("Authorization", "Bearer "+tokens[i%users])
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("request %d: status %d", i, resp.StatusCode)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	handled := 0
+	sc := bufio.NewScanner(&out.buf)
+	for sc.Scan() {
+		line := sc.Text()
+
+		var rec struct {
+			Msg           string `json:"msg"`
+			RequestID     string `json:"request_id"`
+			UserID        int64  `json:"user_id"`
+			WantRequestID string `json:"want_request_id"`
+			WantUserID    int64  `json:"want_user_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if rec.Msg != "handled" {
+			continue
+		}
+		handled++
+
+		// Attributes of other requests would show up as repeated keys
+		if n := strings.Count(line, `"request_id":`); n != 1 {
+			t.Fatalf("record has %d request IDs: %s", n, line)
+		}
+		if n := strings.Count(line, `"user_id":`); n != 1 {
+			t.Fatalf("record has %d user IDs: %s", n, line)
+		}
+		if rec.RequestID != rec.WantRequestID || rec.UserID != rec.WantUserID {
+			t.Fatalf("record of request %s user %d logged as request %s user %d",
+				rec.WantRequestID, rec.WantUserID, rec.RequestID, rec.UserID)
+		}
+	}
+
+	if handled != requests {
+		t.Fatalf("handled %d records, want %d", handled, requests)
+	}
+}
+
+func TestAccessLogOmitsPath(t *testing.T) {
+	var out syncBuffer
+	log := slog.New(slog.NewJSONHandler(&out, nil))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(logger.New(log))
+	r.Get("/calendar/feeds/{token}.ics", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calendar/feeds/s3cr3t.ics", nil))
+
+	line := out.buf.String()
+	if strings.Contains(line, "s3cr3t") {
+		t.Fatalf("access log holds the feed token: %s", line)
+	}
+
+	var rec struct {
+		Route string `json:"route"`
+	}
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatalf("log line %q: %v", line, err)
+	}
+	if rec.Route != "/calendar/feeds/{token}.ics" {
+		t.Fatalf("route %q, want the pattern", rec.Route)
+	}
+}
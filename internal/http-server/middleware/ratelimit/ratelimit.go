@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/go-chi/render"
+)
+
+type Limiter interface {
+	Name() string
+	Allow(string) (bool, time.Duration)
+	Ready(string) (bool, time.Duration)
+}
+
+// PerIP limits the requests of every client address. It goes before the
+// authentication, so that floods never reach the database.
+func PerIP(log *slog.Logger, l Limiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, retry := l.Allow(clientIP(r)); !ok {
+				reject(w, r, log, l, retry)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthFailures blocks the client addresses whose requests keep failing the
+// authentication, l is spent only by the requests auth.Auth rejects for a
+// missing or invalid token or an unknown user. The 401 answers of the
+// handlers and the denied active roles do not count.
+func AuthFailures(log *slog.Logger, l Limiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+
+			if ok, retry := l.Ready(ip); !ok {
+				reject(w, r, log, l, retry)
+				return
+			}
+
+			ctx, failure := auth.TrackFailures(r.Context())
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			switch failure() {
+			case metrics.AuthNoToken, metrics.AuthInvalidToken, metrics.AuthUnknownUser:
+				l.Allow(ip)
+			}
+		})
+	}
+}
+
+// PerUser limits the requests of every authenticated user, writes spend
+// the stricter limit as well. It goes after the authentication.
+func PerUser(log *slog.Logger, all, writes Limiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID := strconv.FormatInt(key.ID, 10)
+
+			if ok, retry := all.Allow(userID); !ok {
+				reject(w, r, log, all, retry)
+				return
+			}
+
+			if isWrite(r.Method) {
+				if ok, retry := writes.Allow(userID); !ok {
+					reject(w, r, log, writes, retry)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// clientIP is the address of the connection, forwarding headers are not
+// trusted as any client can set them
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func reject(w http.ResponseWriter, r *http.Request, log *slog.Logger, l Limiter, retry time.Duration) {
+	metrics.RateLimited.WithLabelValues(l.Name()).Inc()
+
+	sl.FromContext(r.Context(), log).Warn("rate limit exceeded",
+		slog.String("limit", l.Name()),
+		slog.String("remote_ip", clientIP(r)),
+		slog.Duration("retry_after", retry),
+	)
+
+	seconds := max(1, int(math.Ceil(retry.Seconds())))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+	render.Status(r, http.StatusTooManyRequests)
+	render.JSON(w, r, resp.Error("too many requests"))
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const secret = "passphrasewhichneedstobe32bytes!"
+
+// countingLimiter never limits and counts the tokens spent
+type countingLimiter struct {
+	spent int
+}
+
+func (l *countingLimiter) Name() string                       { return "test" }
+func (l *countingLimiter) Allow(string) (bool, time.Duration) { l.spent++; return true, 0 }
+func (l *countingLimiter) Ready(string) (bool, time.Duration) { return true, 0 }
+
+type storage struct{}
+
+func (storage) UserRoles(_ context.Context, email string) (models.Key, error) {
+	if email != "adm@gmail.com" {
+		return models.Key{}, io.EOF
+	}
+	return models.Key{ID: 1, Email: email, Roles: []models.Role{{Name: "admin"}}}, nil
+}
+
+func (storage) RolesVersion(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
+func TestAuthFailuresCountsAuthenticationOnly(t *testing.T) {
+	sign := func(email string) string {
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"email": email}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return tok
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		role      string
+		wantCode  int
+		wantSpent int
+	}{
+		{name: "no token", wantCode: http.StatusUnauthorized, wantSpent: 1},
+		{name: "invalid token", token: "garbage", wantCode: http.StatusUnauthorized, wantSpent: 1},
+		{name: "unknown user", token: sign("nobody@gmail.com"), wantCode: http.StatusUnauthorized, wantSpent: 1},
+		{name: "denied role", token: sign("adm@gmail.com"), role: "teacher", wantCode: http.StatusForbidden},
+		{name: "handler unauthorized", token: sign("adm@gmail.com"), wantCode: http.StatusUnauthorized},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &countingLimiter{}
+
+			// The handler denies every authenticated request
+			h := AuthFailures(log, l)(auth.New(secret, storage{}).Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			})))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.role != "" {
+				r.Header.Set(auth.ActiveRoleHeader, tt.role)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantCode)
+			}
+			if l.spent != tt.wantSpent {
+				t.Fatalf("spent %d tokens, want %d", l.spent, tt.wantSpent)
+			}
+		})
+	}
+}
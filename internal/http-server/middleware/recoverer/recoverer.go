@@ -0,0 +1,45 @@
+package recoverer
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	resp "github.com/arxonic/journal/internal/lib/api/response"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/go-chi/render"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New turns a panic of a handler into a logged error and a JSON 500
+// response instead of a dropped connection
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// The server aborts the response on purpose
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				sl.FromContext(r.Context(), log).Error("handler panicked",
+					slog.String("panic", fmt.Sprint(rec)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				trace.SpanFromContext(r.Context()).SetStatus(codes.Error, fmt.Sprint(rec))
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
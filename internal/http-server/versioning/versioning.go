@@ -0,0 +1,106 @@
+// Package versioning serves generations of the API side by side under /api.
+// A version registers the routes of the one before it and then replaces the
+// handlers whose JSON changed, so the versions share the storage and only
+// the changed endpoints are written twice. The access list of a version is its
+// own, the routes are keyed by their pattern within the version:
+//
+//	v1 := versioning.Version{Name: "v1", Routes: []func(chi.Router){routesV1(policy.New())}}
+//	ac := policy.New()
+//	v2 := versioning.Version{Name: "v2", Routes: []func(chi.Router){routesV1(ac), routesV2(ac)}}
+package versioning
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Prefix of the versioned routes
+const Prefix = "/api"
+
+// Response headers of deprecated versions, RFC 9745 and RFC 8594
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+type Version struct {
+	// Name is the path segment of the version, empty for the unversioned routes
+	Name string
+	// Routes register the handlers in order, later ones replace the routes of
+	// the earlier ones
+	Routes []func(chi.Router)
+	Deprecation
+}
+
+// Deprecation announces that a version is going away. Since is when it was
+// deprecated, zero if it is not, and Sunset when it stops being served.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time
+	// Successor is the version clients should move to
+	Successor *Version
+}
+
+// Path is where the version is mounted
+func (v *Version) Path() string {
+	if v.Name == "" {
+		return ""
+	}
+	return Prefix + "/" + v.Name
+}
+
+// Router builds the routes of the version behind the middlewares. The
+// deprecation is marked before them, so the requests they reject carry the
+// headers too.
+func (v *Version) Router(middlewares chi.Middlewares) chi.Router {
+	r := chi.NewRouter()
+	r.Use(Deprecated(v.Path(), v.Deprecation))
+	r.Use(middlewares...)
+
+	for _, routes := range v.Routes {
+		routes(r)
+	}
+	return r
+}
+
+// Mount serves the versions on r behind the middlewares
+func Mount(r chi.Router, middlewares chi.Middlewares, versions ...*Version) {
+	for _, v := range versions {
+		path := v.Path()
+		if path == "" {
+			path = "/"
+		}
+		r.Mount(path, v.Router(middlewares))
+	}
+}
+
+// Deprecated marks the responses of a deprecated version mounted at prefix.
+// The successor link points to the same resource in the successor version.
+func Deprecated(prefix string, d Deprecation) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d.Since.IsZero() {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set(HeaderDeprecation, fmt.Sprintf("@%d", d.Since.Unix()))
+			if !d.Sunset.IsZero() {
+				h.Set(HeaderSunset, d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Successor != nil {
+				link := d.Successor.Path() + strings.TrimPrefix(r.URL.Path, prefix)
+				h.Add(HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, link))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
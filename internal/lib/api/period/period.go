@@ -0,0 +1,78 @@
+package period
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+// Query parameters understood by listing endpoints:
+//
+//	?academic_year_id=3   only the given academic year
+//	?semester_id=5        only the given semester
+//	?period=all           no filtering at all
+//
+// Without any of them the current academic year is used.
+const (
+	ParamAcademicYear = "academic_year_id"
+	ParamSemester     = "semester_id"
+	ParamPeriod       = "period"
+	PeriodAll         = "all"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("invalid period")
+)
+
+type CurrentPeriodGetter interface {
+	CurrentPeriod(context.Context) (scheme.Period, error)
+}
+
+func FromRequest(r *http.Request, s CurrentPeriodGetter) (scheme.Period, error) {
+	q := r.URL.Query()
+
+	if q.Get(ParamPeriod) == PeriodAll {
+		return scheme.Period{}, nil
+	}
+
+	yearID, err := parseID(q.Get(ParamAcademicYear))
+	if err != nil {
+		return scheme.Period{}, err
+	}
+
+	semesterID, err := parseID(q.Get(ParamSemester))
+	if err != nil {
+		return scheme.Period{}, err
+	}
+
+	if yearID != 0 || semesterID != 0 {
+		return scheme.Period{AcademicYearID: yearID, SemesterID: semesterID}, nil
+	}
+
+	current, err := s.CurrentPeriod(r.Context())
+	if err != nil {
+		if errors.Is(err, store.ErrPeriodNotFound) {
+			return scheme.Period{}, nil
+		}
+		return scheme.Period{}, err
+	}
+
+	return scheme.Period{AcademicYearID: current.AcademicYearID}, nil
+}
+
+func parseID(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidPeriod
+	}
+
+	return id, nil
+}
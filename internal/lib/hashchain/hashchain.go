@@ -0,0 +1,43 @@
+package hashchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+// Genesis is the previous hash of the first audit entry
+const Genesis = ""
+
+// Hash seals the audit entry to the one before it: the hex SHA-256 of the
+// previous hash and every field of the entry but its ID and own hash.
+// Changing, removing or reordering an entry breaks the hashes of all later ones.
+func Hash(prev string, e scheme.AuditEntry) string {
+	fields := []string{
+		prev,
+		e.At.UTC().Format(time.RFC3339Nano),
+		strconv.FormatInt(e.ActorID, 10),
+		e.Action,
+		e.Entity,
+		strconv.FormatInt(e.EntityID, 10),
+		string(e.Before),
+		string(e.After),
+		e.RequestID,
+	}
+
+	// Lengths keep "ab"+"c" apart from "a"+"bc"
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(strconv.Itoa(len(f)))
+		b.WriteByte(':')
+		b.WriteString(f)
+		b.WriteByte('\n')
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
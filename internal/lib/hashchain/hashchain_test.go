@@ -0,0 +1,67 @@
+package hashchain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+func TestHash(t *testing.T) {
+	at := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	base := scheme.AuditEntry{
+		ID:        1,
+		At:        at,
+		ActorID:   1,
+		Action:    "course.create",
+		Entity:    "course",
+		EntityID:  7,
+		After:     json.RawMessage(`{"course_name":"CS-21"}`),
+		RequestID: "req-1",
+	}
+	want := Hash(Genesis, base)
+
+	tests := []struct {
+		name   string
+		prev   string
+		change func(e *scheme.AuditEntry)
+		same   bool
+	}{
+		{name: "same entry", change: func(e *scheme.AuditEntry) {}, same: true},
+		{name: "own ID and hash ignored", change: func(e *scheme.AuditEntry) { e.ID, e.PrevHash, e.Hash = 2, "x", "y" }, same: true},
+		{name: "same instant in another zone", change: func(e *scheme.AuditEntry) { e.At = at.In(time.FixedZone("MSK", 3*3600)) }, same: true},
+		{name: "previous hash", prev: "abc", change: func(e *scheme.AuditEntry) {}},
+		{name: "time", change: func(e *scheme.AuditEntry) { e.At = at.Add(time.Nanosecond) }},
+		{name: "actor", change: func(e *scheme.AuditEntry) { e.ActorID = 2 }},
+		{name: "action", change: func(e *scheme.AuditEntry) { e.Action = "course.delete" }},
+		{name: "entity", change: func(e *scheme.AuditEntry) { e.Entity = "assignment" }},
+		{name: "entity ID", change: func(e *scheme.AuditEntry) { e.EntityID = 8 }},
+		{name: "before", change: func(e *scheme.AuditEntry) { e.Before = json.RawMessage(`{}`) }},
+		{name: "after", change: func(e *scheme.AuditEntry) { e.After = json.RawMessage(`{"course_name":"CS-22"}`) }},
+		{name: "request", change: func(e *scheme.AuditEntry) { e.RequestID = "req-2" }},
+		{
+			name: "field boundary moved",
+			change: func(e *scheme.AuditEntry) {
+				e.Action, e.Entity = "course.createc", "ourse"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := base
+			tt.change(&e)
+
+			prev := Genesis
+			if tt.prev != "" {
+				prev = tt.prev
+			}
+
+			got := Hash(prev, e)
+			if (got == want) != tt.same {
+				t.Fatalf("Hash = %s, base %s, want same %v", got, want, tt.same)
+			}
+		})
+	}
+}
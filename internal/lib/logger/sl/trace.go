@@ -0,0 +1,44 @@
+package sl
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TraceIDKey = "trace_id"
+
+// TraceHandler adds the trace_id and span_id of the span in the context to
+// the records logged with one, e.g. with InfoContext, unless the logger
+// already carries a trace_id
+type TraceHandler struct {
+	slog.Handler
+	traced bool
+}
+
+func NewTraceHandler(h slog.Handler) *TraceHandler {
+	return &TraceHandler{Handler: h}
+}
+
+func (h *TraceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !h.traced {
+		r.AddAttrs(
+			slog.String(TraceIDKey, sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	traced := h.traced || slices.ContainsFunc(attrs, func(a slog.Attr) bool {
+		return a.Key == TraceIDKey
+	})
+	return &TraceHandler{Handler: h.Handler.WithAttrs(attrs), traced: traced}
+}
+
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithGroup(name), traced: h.traced}
+}
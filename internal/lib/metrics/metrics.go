@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "journal"
+
+// Reasons of rejected authentication
+const (
+	AuthNoToken      = "no_token"
+	AuthInvalidToken = "invalid_token"
+	AuthUnknownUser  = "unknown_user"
+	AuthRoleDenied   = "role_denied"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	StorageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "call_duration_seconds",
+		Help:      "Duration of storage calls by method, the count is the number of calls.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method"})
+
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "failures_total",
+		Help:      "Rejected authentications by reason.",
+	}, []string{"reason"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by a rate limit, by limit.",
+	}, []string{"limit"})
+
+	ExamSignUps = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exam_signups_total",
+		Help:      "Students signed up for exams.",
+	})
+
+	GradesPosted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grades_posted_total",
+		Help:      "Exam grades posted.",
+	})
+)
+
+// ObserveStorage records a storage call started at start, fn is the
+// "storage.sqlite.Method" name of the method
+func ObserveStorage(fn string, start time.Time) {
+	method := fn[strings.LastIndexByte(fn, '.')+1:]
+	StorageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,269 @@
+// Package openapi describes an HTTP API as an OpenAPI 3 document. Schemas are
+// reflected from the Go types the handlers decode and render, so the document
+// follows the handlers without being edited by hand.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+
+	names map[reflect.Type]string
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lower case HTTP methods to the operations of a path
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string     `json:"$ref,omitempty"`
+	Type                 string     `json:"type,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	Nullable             bool       `json:"nullable,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Items                *Schema    `json:"items,omitempty"`
+	Properties           Properties `json:"properties,omitempty"`
+	AdditionalProperties *Schema    `json:"additionalProperties,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+}
+
+// Property is a named schema of an object, kept in the order of the struct fields
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+type Properties []Property
+
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("openapi: unexpected property name %v", tok)
+		}
+
+		var schema Schema
+		if err := dec.Decode(&schema); err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: name, Schema: &schema})
+	}
+	return nil
+}
+
+const (
+	ContentJSON = "application/json"
+
+	securityBearer = "bearerAuth"
+)
+
+// New returns an empty document of the API authenticated by a bearer JWT
+func New(title, version, description string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				securityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{securityBearer: {}}},
+		names:    make(map[reflect.Type]string),
+	}
+}
+
+// Op describes an endpoint by the values its handler decodes and renders
+type Op struct {
+	ID          string
+	Tag         string
+	Summary     string
+	Description string
+	Query       []Parameter
+	// Request is decoded from the JSON body, nil for requests without a body
+	Request any
+	// Response is rendered as JSON with status 200
+	Response any
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Add documents the operation on the path. Path parameters are taken from the
+// path pattern and are integer IDs.
+func (d *Document) Add(method, path string, op Op) {
+	operation := &Operation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Parameters:  append([]Parameter(nil), op.Query...),
+		Responses: map[string]Response{
+			"401": {Description: "The role of the user has no access to the resource"},
+			"429": {
+				Description: "Too many requests",
+				Headers: map[string]Header{
+					"Retry-After": {Description: "Seconds until the request may be repeated", Schema: &Schema{Type: "integer"}},
+				},
+			},
+		},
+	}
+	if op.Tag != "" {
+		operation.Tags = []string{op.Tag}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer", Format: "int64"},
+		})
+	}
+
+	if op.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{ContentJSON: {Schema: d.Schema(op.Request)}},
+		}
+	}
+
+	ok := Response{Description: "Result of the operation, failures carry status Error and a message"}
+	if op.Response != nil {
+		ok.Content = map[string]MediaType{ContentJSON: {Schema: d.Schema(op.Response)}}
+	}
+	operation.Responses["200"] = ok
+
+	item, found := d.Paths[path]
+	if !found {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = operation
+}
+
+// Operations lists the documented methods and paths in a stable order
+func (d *Document) Operations() [][2]string {
+	ops := make([][2]string, 0)
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, [2]string{strings.ToUpper(method), path})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i][1] != ops[j][1] {
+			return ops[i][1] < ops[j][1]
+		}
+		return ops[i][0] < ops[j][0]
+	})
+	return ops
+}
@@ -0,0 +1,18 @@
+package readiness
+
+import "sync/atomic"
+
+// State tells whether the server accepts traffic. It is not ready until the
+// server starts listening and stops being ready as soon as it starts draining,
+// so that load balancers move new requests elsewhere.
+type State struct {
+	ready atomic.Bool
+}
+
+func (s *State) Set(ready bool) {
+	s.ready.Store(ready)
+}
+
+func (s *State) Ready() bool {
+	return s.ready.Load()
+}
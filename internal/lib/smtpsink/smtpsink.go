@@ -0,0 +1,190 @@
+package smtpsink
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+)
+
+const hostname = "journal-smtp-sink"
+
+// Message is an email accepted by the sink
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+	Raw     []byte
+}
+
+// Sink is a fake SMTP server for local and test runs. It accepts every
+// email, keeps it in memory and logs it instead of delivering it.
+type Sink struct {
+	log      *slog.Logger
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+
+	wg sync.WaitGroup
+}
+
+// Start listens on addr, "localhost:0" picks a free port
+func Start(addr string, log *slog.Logger) (*Sink, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sink{
+		log:      log.With(slog.String("component", "smtp-sink")),
+		listener: l,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *Sink) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Messages returns the emails accepted so far
+func (s *Sink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Sink) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Sink) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.log.Error("failed to accept connection", sl.Err(err))
+			}
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			if err := s.session(textproto.NewConn(conn)); err != nil && !errors.Is(err, io.EOF) {
+				s.log.Error("smtp session failed", sl.Err(err))
+			}
+		}()
+	}
+}
+
+// session speaks the subset of RFC 5321 net/smtp clients need
+func (s *Sink) session(c *textproto.Conn) error {
+	if err := c.PrintfLine("220 %s ESMTP", hostname); err != nil {
+		return err
+	}
+
+	var msg Message
+
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			err = c.PrintfLine("250-%s\r\n250-8BITMIME\r\n250 SMTPUTF8", hostname)
+		case "HELO":
+			err = c.PrintfLine("250 %s", hostname)
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			err = c.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			err = c.PrintfLine("250 OK")
+		case "DATA":
+			if len(msg.To) == 0 {
+				err = c.PrintfLine("503 no recipients")
+				break
+			}
+			if err = c.PrintfLine("354 end data with <CR><LF>.<CR><LF>"); err != nil {
+				return err
+			}
+
+			msg.Raw, err = c.ReadDotBytes()
+			if err != nil {
+				return err
+			}
+
+			s.accept(msg)
+			msg = Message{}
+
+			err = c.PrintfLine("250 OK")
+		case "RSET":
+			msg = Message{}
+			err = c.PrintfLine("250 OK")
+		case "NOOP":
+			err = c.PrintfLine("250 OK")
+		case "QUIT":
+			return c.PrintfLine("221 bye")
+		default:
+			err = c.PrintfLine("502 command not implemented")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Sink) accept(msg Message) {
+	if m, err := mail.ReadMessage(bytes.NewReader(msg.Raw)); err == nil {
+		var dec mime.WordDecoder
+		if subject, err := dec.DecodeHeader(m.Header.Get("Subject")); err == nil {
+			msg.Subject = subject
+		}
+		if body, err := io.ReadAll(m.Body); err == nil {
+			msg.Body = string(body)
+		}
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	s.log.Info("email received",
+		slog.String("from", msg.From),
+		slog.String("to", strings.Join(msg.To, ", ")),
+		slog.String("subject", msg.Subject),
+	)
+	s.log.Debug("email body", slog.String("body", msg.Body))
+}
+
+// address extracts the mailbox of "FROM:<a@b> SIZE=10" and "TO:<a@b>"
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
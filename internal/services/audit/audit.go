@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/http-server/middleware/auth"
+	"github.com/arxonic/journal/internal/lib/hashchain"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const verifyBatch = 500
+
+// Recorder prepares the audit entries of the writes done by the handlers. The
+// storage appends them within the transaction of the write, so a change is
+// never kept without its entry.
+type Recorder struct {
+	now func() time.Time
+}
+
+func New() *Recorder {
+	return &Recorder{
+		now: time.Now,
+	}
+}
+
+// Entry describes the action of the request's user on the entity. before and
+// after are stored as JSON, nil is left out.
+func (a *Recorder) Entry(r *http.Request, action, entity string, entityID int64, before, after any) (*scheme.AuditEntry, error) {
+	e := &scheme.AuditEntry{
+		At:        a.now(),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	if key, ok := r.Context().Value(auth.ContextAuthMiddlewareKey).(*models.Key); ok {
+		e.ActorID = key.ID
+	}
+
+	var err error
+	if e.Before, err = marshal(before); err != nil {
+		return nil, fmt.Errorf("audit before value: %w", err)
+	}
+	if e.After, err = marshal(after); err != nil {
+		return nil, fmt.Errorf("audit after value: %w", err)
+	}
+
+	return e, nil
+}
+
+func marshal(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+type Chain interface {
+	AuditChain(context.Context, int64, int) ([]scheme.AuditEntry, error)
+}
+
+// Verify walks the audit log from the first entry and recomputes every hash
+func Verify(ctx context.Context, c Chain) (scheme.AuditVerification, error) {
+	res := scheme.AuditVerification{Valid: true}
+
+	prev := hashchain.Genesis
+	var lastID int64
+
+	for {
+		entries, err := c.AuditChain(ctx, lastID, verifyBatch)
+		if err != nil {
+			return scheme.AuditVerification{}, err
+		}
+
+		for _, e := range entries {
+			if e.PrevHash != prev || hashchain.Hash(prev, e) != e.Hash {
+				res.Valid = false
+				res.BrokenID = e.ID
+				return res, nil
+			}
+
+			prev = e.Hash
+			lastID = e.ID
+			res.Checked++
+		}
+
+		if len(entries) < verifyBatch {
+			return res, nil
+		}
+	}
+}
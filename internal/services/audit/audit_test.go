@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/hashchain"
+)
+
+type chain []scheme.AuditEntry
+
+func (c chain) AuditChain(_ context.Context, afterID int64, limit int) ([]scheme.AuditEntry, error) {
+	var res []scheme.AuditEntry
+	for _, e := range c {
+		if e.ID > afterID && len(res) < limit {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// build chains n entries the way the storage appends them
+func build(n int) chain {
+	c := make(chain, 0, n)
+	prev := hashchain.Genesis
+	for i := 1; i <= n; i++ {
+		e := scheme.AuditEntry{
+			ID:       int64(i),
+			At:       time.Date(2024, 6, 10, 12, 0, i, 0, time.UTC),
+			ActorID:  1,
+			Action:   "course.create",
+			Entity:   "course",
+			EntityID: int64(i),
+			PrevHash: prev,
+		}
+		e.Hash = hashchain.Hash(prev, e)
+		prev = e.Hash
+		c = append(c, e)
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		chain  chain
+		tamper func(c chain) chain
+		want   scheme.AuditVerification
+	}{
+		{name: "empty", chain: build(0), want: scheme.AuditVerification{Valid: true}},
+		{name: "intact", chain: build(3), want: scheme.AuditVerification{Valid: true, Checked: 3}},
+		{
+			name:  "several batches",
+			chain: build(2*verifyBatch + 1),
+			want:  scheme.AuditVerification{Valid: true, Checked: 2*verifyBatch + 1},
+		},
+		{
+			name:   "changed field",
+			chain:  build(3),
+			tamper: func(c chain) chain { c[1].ActorID = 2; return c },
+			want:   scheme.AuditVerification{BrokenID: 2, Checked: 1},
+		},
+		{
+			name:  "rehashed entry",
+			chain: build(3),
+			tamper: func(c chain) chain {
+				c[1].EntityID = 9
+				c[1].Hash = hashchain.Hash(c[1].PrevHash, c[1])
+				return c
+			},
+			want: scheme.AuditVerification{BrokenID: 3, Checked: 2},
+		},
+		{
+			name:   "removed entry",
+			chain:  build(3),
+			tamper: func(c chain) chain { return append(c[:1], c[2:]...) },
+			want:   scheme.AuditVerification{BrokenID: 3, Checked: 1},
+		},
+		{
+			name:   "reordered entries",
+			chain:  build(3),
+			tamper: func(c chain) chain { return chain{c[0], c[2], c[1]} },
+			want:   scheme.AuditVerification{BrokenID: 3, Checked: 1},
+		},
+		{
+			name:   "broken in a later batch",
+			chain:  build(verifyBatch + 2),
+			tamper: func(c chain) chain { c[verifyBatch+1].Action = "course.delete"; return c },
+			want:   scheme.AuditVerification{BrokenID: verifyBatch + 2, Checked: verifyBatch + 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.chain
+			if tt.tamper != nil {
+				c = tt.tamper(c)
+			}
+
+			got, err := Verify(context.Background(), c)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Verify = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package bus
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how far a subscriber may fall behind before it is
+// dropped; the client then reconnects and resumes from its Last-Event-ID
+const subscriberBuffer = 64
+
+// Event is a live update. Events without recipients go to every user.
+type Event struct {
+	ID         string
+	Kind       string
+	Recipients []int64
+	Data       any
+	At         time.Time
+
+	seq uint64
+}
+
+func (e Event) For(userID int64) bool {
+	return len(e.Recipients) == 0 || slices.Contains(e.Recipients, userID)
+}
+
+// Bus is an in-process publish/subscribe hub of live updates. It keeps the
+// latest events so that reconnecting subscribers can resume where they left.
+// Event IDs carry the epoch of the bus, IDs of a previous process are never
+// resumed from.
+type Bus struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	history []Event
+	size    int
+	subs    map[*subscriber]struct{}
+	closed  bool
+}
+
+type subscriber struct {
+	userID int64
+	ch     chan Event
+}
+
+// New keeps the latest historySize events for resumption
+func New(historySize int) *Bus {
+	return &Bus{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:  historySize,
+		subs:  make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish sends the event to the subscribed recipients, to every user if none are given
+func (b *Bus) Publish(kind string, data any, recipients ...int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+
+	e := Event{
+		ID:         fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Kind:       kind,
+		Recipients: recipients,
+		Data:       data,
+		At:         time.Now(),
+		seq:        b.seq,
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.size {
+		b.history = slices.Delete(b.history, 0, len(b.history)-b.size)
+	}
+
+	for sub := range b.subs {
+		if !e.For(sub.userID) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			// Too slow, the client resumes after reconnecting
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe streams the events of the user. The events published after
+// lastEventID are replayed first; resync is true if they cannot be, because
+// the ID is unknown or already out of the history, and the client has to
+// reload its state. The channel is closed when the subscriber is dropped or
+// cancel is called.
+func (b *Bus) Subscribe(userID int64, lastEventID string) (replay []Event, resync bool, events <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID != "" {
+		replay, resync = b.since(userID, lastEventID)
+	}
+
+	sub := &subscriber{
+		userID: userID,
+		ch:     make(chan Event, subscriberBuffer),
+	}
+
+	if b.closed {
+		close(sub.ch)
+		return replay, resync, sub.ch, func() {}
+	}
+
+	b.subs[sub] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+
+	return replay, resync, sub.ch, cancel
+}
+
+// Close drops every subscriber and refuses new ones, so that open streams
+// end and the server can shut down. Clients resume from another instance.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *Bus) since(userID int64, lastEventID string) ([]Event, bool) {
+	epoch, s, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != b.epoch {
+		return nil, true
+	}
+
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || seq > b.seq {
+		return nil, true
+	}
+
+	// Events after seq must all still be in the history
+	if seq < b.seq && (len(b.history) == 0 || b.history[0].seq > seq+1) {
+		return nil, true
+	}
+
+	var replay []Event
+	for _, e := range b.history {
+		if e.seq > seq && e.For(userID) {
+			replay = append(replay, e)
+		}
+	}
+
+	return replay, false
+}
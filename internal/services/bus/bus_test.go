@@ -0,0 +1,80 @@
+package bus
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubscribeResumes(t *testing.T) {
+	const user = 1
+
+	b := New(4)
+
+	// seq 1..6, the history keeps 3..6, seq 4 is for another user
+	ids := make([]string, 0)
+	for i := 1; i <= 6; i++ {
+		if i == 4 {
+			b.Publish("kind", i, 2)
+		} else {
+			b.Publish("kind", i)
+		}
+		ids = append(ids, b.history[len(b.history)-1].ID)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		replay      []int
+		resync      bool
+	}{
+		{name: "fresh"},
+		{name: "latest", lastEventID: ids[5]},
+		{name: "within history", lastEventID: ids[2], replay: []int{5, 6}},
+		{name: "first kept", lastEventID: ids[1], replay: []int{3, 5, 6}},
+		{name: "out of history", lastEventID: ids[0], resync: true},
+		{name: "other epoch", lastEventID: "old-3", resync: true},
+		{name: "malformed", lastEventID: "garbage", resync: true},
+		{name: "bad sequence", lastEventID: b.epoch + "-x", resync: true},
+		{name: "ahead", lastEventID: fmt.Sprintf("%s-%d", b.epoch, 7), resync: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, resync, _, cancel := b.Subscribe(user, tt.lastEventID)
+			defer cancel()
+
+			if resync != tt.resync {
+				t.Fatalf("resync = %v, want %v", resync, tt.resync)
+			}
+
+			got := make([]int, 0, len(replay))
+			for _, e := range replay {
+				got = append(got, e.Data.(int))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(append([]int{}, tt.replay...)) {
+				t.Fatalf("replay = %v, want %v", got, tt.replay)
+			}
+		})
+	}
+}
+
+func TestSubscribeStreamsOwnEvents(t *testing.T) {
+	b := New(4)
+
+	_, _, events, cancel := b.Subscribe(1, "")
+
+	b.Publish("kind", "other", 2)
+	b.Publish("kind", "own", 1)
+	b.Publish("kind", "everyone")
+
+	for _, want := range []string{"own", "everyone"} {
+		if e := <-events; e.Data != want {
+			t.Fatalf("event = %v, want %v", e.Data, want)
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("events still open after cancel")
+	}
+}
@@ -0,0 +1,68 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+// Kinds lists the known academic event kinds
+var Kinds = []string{
+	scheme.EventTerm,
+	scheme.EventExamSession,
+	scheme.EventHoliday,
+	scheme.EventRegistration,
+	scheme.EventDeadline,
+}
+
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the day of t falls within the event, both ends included
+func Contains(event scheme.AcademicEvent, t time.Time) bool {
+	day := dateOf(t)
+	return !day.Before(dateOf(event.DateFrom)) && !day.After(dateOf(event.DateTo))
+}
+
+// Filter returns the events of the kind
+func Filter(events []scheme.AcademicEvent, kind string) []scheme.AcademicEvent {
+	var res []scheme.AcademicEvent
+	for _, e := range events {
+		if e.Kind == kind {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// At returns the events of the kind the day of t falls within
+func At(events []scheme.AcademicEvent, kind string, t time.Time) []scheme.AcademicEvent {
+	var res []scheme.AcademicEvent
+	for _, e := range Filter(events, kind) {
+		if Contains(e, t) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Allowed reports whether t falls within a window of the kind. A calendar
+// without windows of the kind puts no restriction, configured is false then.
+func Allowed(events []scheme.AcademicEvent, kind string, t time.Time) (ok, configured bool) {
+	windows := Filter(events, kind)
+	if len(windows) == 0 {
+		return true, false
+	}
+	return len(At(events, kind, t)) > 0, true
+}
+
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,44 @@
+package gradebook
+
+import (
+	"math"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/services/grading"
+)
+
+// Total computes the student's result in percent from the component scores.
+//
+// weighted_mean: sum(weight * score / max_score) / sum(weight) * 100
+// weighted_sum:  sum(weight * score), weights turn scores into rating points
+//
+// Missing scores count as zero, the result is capped to 0-100.
+func Total(formula string, components []scheme.Component, scores map[int64]float64) float64 {
+	var total, weights float64
+
+	for _, c := range components {
+		score := scores[c.ID]
+
+		switch formula {
+		case scheme.FormulaWeightedSum:
+			total += c.Weight * score
+		default:
+			total += c.Weight * score / c.MaxScore
+			weights += c.Weight
+		}
+	}
+
+	if formula != scheme.FormulaWeightedSum {
+		if weights == 0 {
+			return 0
+		}
+		total = total / weights * 100
+	}
+
+	return math.Max(0, math.Min(100, total))
+}
+
+// Suggest converts the total in percent to the assignment scale through the 100-point rating scale
+func Suggest(total float64, rating, scale scheme.Scale, rules []scheme.Conversion) (int64, error) {
+	return grading.Convert(rules, rating.ID, scale.ID, int64(math.Round(total)))
+}
@@ -0,0 +1,57 @@
+package grading
+
+import (
+	"errors"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+var (
+	ErrNoConversion = errors.New("no conversion between scales")
+)
+
+// Valid reports whether the value fits the scale
+func Valid(scale scheme.Scale, value int64) bool {
+	return value >= scale.Min && value <= scale.Max
+}
+
+// Convert maps the value of one scale to another using the conversion rules.
+// Values of the same scale are returned as is.
+func Convert(rules []scheme.Conversion, fromScaleID, toScaleID, value int64) (int64, error) {
+	if fromScaleID == toScaleID {
+		return value, nil
+	}
+
+	for _, rule := range rules {
+		if rule.FromScaleID == fromScaleID && rule.ToScaleID == toScaleID &&
+			value >= rule.FromMin && value <= rule.FromMax {
+			return rule.ToValue, nil
+		}
+	}
+
+	return 0, ErrNoConversion
+}
+
+// Average converts the grades to the scale and returns their mean.
+// Grades that cannot be converted (e.g. pass/fail credits) are skipped,
+// ok is false if none are left.
+func Average(rules []scheme.Conversion, toScaleID int64, grades []scheme.Grade) (avg float64, ok bool) {
+	var sum float64
+	var n int
+
+	for _, g := range grades {
+		v, err := Convert(rules, g.ScaleID, toScaleID, g.Grade)
+		if err != nil {
+			continue
+		}
+
+		sum += float64(v)
+		n++
+	}
+
+	if n == 0 {
+		return 0, false
+	}
+
+	return sum / float64(n), true
+}
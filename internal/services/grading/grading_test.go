@@ -0,0 +1,103 @@
+package grading
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+const (
+	fivePoint = 1
+	passFail  = 2
+	rating100 = 5
+)
+
+// A part of the conversions seeded by the migrations
+var rules = []scheme.Conversion{
+	{FromScaleID: rating100, ToScaleID: fivePoint, FromMin: 0, FromMax: 59, ToValue: 2},
+	{FromScaleID: rating100, ToScaleID: fivePoint, FromMin: 60, FromMax: 74, ToValue: 3},
+	{FromScaleID: rating100, ToScaleID: fivePoint, FromMin: 75, FromMax: 89, ToValue: 4},
+	{FromScaleID: rating100, ToScaleID: fivePoint, FromMin: 90, FromMax: 100, ToValue: 5},
+	{FromScaleID: fivePoint, ToScaleID: passFail, FromMin: 1, FromMax: 2, ToValue: 0},
+	{FromScaleID: fivePoint, ToScaleID: passFail, FromMin: 3, FromMax: 5, ToValue: 1},
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int64
+		value    int64
+		want     int64
+		err      error
+	}{
+		{name: "same scale", from: fivePoint, to: fivePoint, value: 4, want: 4},
+		{name: "lower bound", from: rating100, to: fivePoint, value: 0, want: 2},
+		{name: "range start", from: rating100, to: fivePoint, value: 60, want: 3},
+		{name: "range end", from: rating100, to: fivePoint, value: 74, want: 3},
+		{name: "upper bound", from: rating100, to: fivePoint, value: 100, want: 5},
+		{name: "to pass fail", from: fivePoint, to: passFail, value: 3, want: 1},
+		{name: "out of range", from: rating100, to: fivePoint, value: 101, err: ErrNoConversion},
+		{name: "no rule back", from: fivePoint, to: rating100, value: 5, err: ErrNoConversion},
+		{name: "no rule between scales", from: passFail, to: fivePoint, value: 1, err: ErrNoConversion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(rules, tt.from, tt.to, tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("Convert(%d) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		to     int64
+		grades []scheme.Grade
+		want   float64
+		ok     bool
+	}{
+		{name: "no grades", to: fivePoint},
+		{
+			name:   "same scale",
+			to:     fivePoint,
+			grades: []scheme.Grade{{Grade: 5, ScaleID: fivePoint}, {Grade: 4, ScaleID: fivePoint}},
+			want:   4.5,
+			ok:     true,
+		},
+		{
+			name:   "converted",
+			to:     fivePoint,
+			grades: []scheme.Grade{{Grade: 5, ScaleID: fivePoint}, {Grade: 80, ScaleID: rating100}, {Grade: 61, ScaleID: rating100}},
+			want:   4,
+			ok:     true,
+		},
+		{
+			name:   "credits skipped",
+			to:     fivePoint,
+			grades: []scheme.Grade{{Grade: 1, ScaleID: passFail}, {Grade: 3, ScaleID: fivePoint}},
+			want:   3,
+			ok:     true,
+		},
+		{
+			name:   "only credits",
+			to:     fivePoint,
+			grades: []scheme.Grade{{Grade: 1, ScaleID: passFail}, {Grade: 0, ScaleID: passFail}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Average(rules, tt.to, tt.grades)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("Average = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
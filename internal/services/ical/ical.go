@@ -0,0 +1,210 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+const (
+	prodID    = "-//arxonic//journal//RU"
+	uidDomain = "journal"
+
+	// Layouts of DATE, floating local DATE-TIME and UTC DATE-TIME values
+	dateLayout  = "20060102"
+	localLayout = "20060102T150405"
+	utcLayout   = "20060102T150405Z"
+
+	timeOfDay     = "15:04"
+	maxLineOctets = 75
+	examDuration  = 2 * time.Hour
+)
+
+// Event is a VEVENT. All-day events use DATE values, floating events carry
+// no time zone and are shown in the calendar's own one.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Floating    bool
+	RRule       string
+}
+
+// Render writes the events as an RFC 5545 VCALENDAR
+func Render(name string, events []Event, stamp time.Time) []byte {
+	var b bytes.Buffer
+
+	line(&b, "BEGIN:VCALENDAR")
+	line(&b, "VERSION:2.0")
+	line(&b, "PRODID:"+prodID)
+	line(&b, "CALSCALE:GREGORIAN")
+	line(&b, "METHOD:PUBLISH")
+	line(&b, "X-WR-CALNAME:"+escape(name))
+
+	for _, e := range events {
+		line(&b, "BEGIN:VEVENT")
+		line(&b, "UID:"+e.UID)
+		line(&b, "DTSTAMP:"+stamp.UTC().Format(utcLayout))
+
+		switch {
+		case e.AllDay:
+			line(&b, "DTSTART;VALUE=DATE:"+e.Start.Format(dateLayout))
+			line(&b, "DTEND;VALUE=DATE:"+e.End.Format(dateLayout))
+		case e.Floating:
+			line(&b, "DTSTART:"+e.Start.Format(localLayout))
+			line(&b, "DTEND:"+e.End.Format(localLayout))
+		default:
+			line(&b, "DTSTART:"+e.Start.UTC().Format(utcLayout))
+			line(&b, "DTEND:"+e.End.UTC().Format(utcLayout))
+		}
+
+		if e.RRule != "" {
+			line(&b, "RRULE:"+e.RRule)
+		}
+
+		line(&b, "SUMMARY:"+escape(e.Summary))
+		if e.Description != "" {
+			line(&b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.Location != "" {
+			line(&b, "LOCATION:"+escape(e.Location))
+		}
+		line(&b, "END:VEVENT")
+	}
+
+	line(&b, "END:VCALENDAR")
+
+	return b.Bytes()
+}
+
+// ExamEvent turns an exam registration or an exam slot into an event.
+// Exams without a time of day become all-day events.
+func ExamEvent(e scheme.ExamEvent, userID int64) Event {
+	kind := "exam"
+	if e.Students > 0 {
+		kind = "exam-slot"
+	}
+
+	event := Event{
+		UID:     fmt.Sprintf("%s-%d-%s-%d@%s", kind, e.AssignmentID, e.ExamDate.UTC().Format(utcLayout), userID, uidDomain),
+		Summary: "Экзамен: " + e.DisciplineName,
+		Start:   e.ExamDate,
+	}
+
+	event.Description = "Курс: " + e.CourseName
+	if e.Students > 0 {
+		event.Description += fmt.Sprintf("\nЗаписано студентов: %d", e.Students)
+	}
+
+	y, m, d := e.ExamDate.UTC().Date()
+	if e.ExamDate.Equal(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)) {
+		event.AllDay = true
+		event.Start = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		event.End = event.Start.AddDate(0, 0, 1)
+	} else {
+		event.End = e.ExamDate.Add(examDuration)
+	}
+
+	return event
+}
+
+// LessonEvent turns a timetable lesson into a weekly recurring event within its term.
+// Weeks are counted from the week the term starts in, that week is odd.
+// ok is false if the lesson never takes place within the term.
+func LessonEvent(l scheme.LessonEvent, userID int64) (event Event, ok bool) {
+	start, err := time.Parse(timeOfDay, l.StartTime)
+	if err != nil {
+		return Event{}, false
+	}
+	end, err := time.Parse(timeOfDay, l.EndTime)
+	if err != nil {
+		return Event{}, false
+	}
+
+	termStart := dateOf(l.TermStart)
+	termEnd := dateOf(l.TermEnd)
+
+	// First day of the term falling on the lesson's weekday
+	first := termStart.AddDate(0, 0, (l.Weekday-isoWeekday(termStart)+7)%7)
+
+	interval := 1
+	if l.WeekParity == scheme.WeekOdd || l.WeekParity == scheme.WeekEven {
+		interval = 2
+
+		week := int(first.Sub(mondayOf(termStart)).Hours()/24/7) + 1
+		if (week%2 == 1) != (l.WeekParity == scheme.WeekOdd) {
+			first = first.AddDate(0, 0, 7)
+		}
+	}
+
+	if first.After(termEnd) {
+		return Event{}, false
+	}
+
+	event = Event{
+		UID:         fmt.Sprintf("lesson-%d-%d@%s", l.ID, userID, uidDomain),
+		Summary:     l.DisciplineName,
+		Description: "Курс: " + l.CourseName,
+		Location:    l.RoomName,
+		Start:       first.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute),
+		End:         first.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute),
+		Floating:    true,
+		RRule: fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d;UNTIL=%s",
+			interval, termEnd.Add(24*time.Hour-time.Second).Format(localLayout)),
+	}
+
+	return event, true
+}
+
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// isoWeekday returns 1 for Monday through 7 for Sunday
+func isoWeekday(t time.Time) int {
+	wd := int(t.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
+
+func mondayOf(t time.Time) time.Time {
+	return t.AddDate(0, 0, 1-isoWeekday(t))
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// line writes a content line folded at 75 octets without splitting UTF-8 sequences
+func line(b *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8Start(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space that counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+func utf8Start(c byte) bool {
+	return c&0xC0 != 0x80
+}
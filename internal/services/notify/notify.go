@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+)
+
+const dateLayout = "02.01.2006"
+
+type Storage interface {
+	User(context.Context, int64) (scheme.User, error)
+	UserEmail(context.Context, int64) (string, error)
+	NotificationPreferences(context.Context, int64) (scheme.NotificationPreferences, error)
+	Course(context.Context, int64) (scheme.Course, error)
+	Discipline(context.Context, int64) (scheme.Discipline, error)
+	SaveNotification(context.Context, *scheme.Notification) (int64, error)
+}
+
+// Notifier turns domain events into emails in the outbox. The outbox is sent
+// by the Dispatcher, so a failing mail server never fails a request.
+type Notifier struct {
+	log *slog.Logger
+	s   Storage
+}
+
+func New(log *slog.Logger, s Storage) *Notifier {
+	return &Notifier{
+		log: log,
+		s:   s,
+	}
+}
+
+// Notify queues the notification of the kind for the user unless the user
+// opted out of it. Errors are logged, not returned.
+func (n *Notifier) Notify(ctx context.Context, kind string, userID int64, data scheme.NotificationData) {
+	// The change is already stored, a client going away must not lose its notification
+	ctx = context.WithoutCancel(ctx)
+
+	log := sl.FromContext(ctx, n.log).With(
+		slog.String("component", "notify"),
+		slog.String("kind", kind),
+		slog.Int64("recipient_id", userID),
+	)
+
+	id, err := n.enqueue(ctx, log, kind, userID, data)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to queue notification", sl.Err(err))
+		return
+	}
+	if id != 0 {
+		log.DebugContext(ctx, "notification queued", slog.Int64("notification_id", id))
+	}
+}
+
+func (n *Notifier) enqueue(ctx context.Context, log *slog.Logger, kind string, userID int64, data scheme.NotificationData) (int64, error) {
+	prefs, err := n.s.NotificationPreferences(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if slices.Contains(prefs.Disabled, kind) {
+		return 0, nil
+	}
+
+	email, err := n.s.UserEmail(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	view, err := n.view(ctx, log, userID, data)
+	if err != nil {
+		return 0, err
+	}
+
+	subject, body, err := Render(prefs.Language, kind, view)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.s.SaveNotification(ctx, &scheme.Notification{
+		UserID:        userID,
+		Kind:          kind,
+		Email:         email,
+		Subject:       subject,
+		Body:          body,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// view resolves the names the event refers to. The discipline is only a part
+// of the text, the notification is sent without it if it cannot be resolved.
+func (n *Notifier) view(ctx context.Context, log *slog.Logger, userID int64, data scheme.NotificationData) (View, error) {
+	var v View
+
+	recipient, err := n.s.User(ctx, userID)
+	if err != nil {
+		return View{}, fmt.Errorf("recipient: %w", err)
+	}
+	v.Recipient = strings.TrimSpace(recipient.FirstName + " " + recipient.Patronymic)
+
+	if data.StudentID != 0 {
+		student, err := n.s.User(ctx, data.StudentID)
+		if err != nil {
+			return View{}, fmt.Errorf("student: %w", err)
+		}
+		v.Student = fullName(student)
+	}
+
+	if data.CourseID != 0 {
+		course, err := n.s.Course(ctx, data.CourseID)
+		if err != nil {
+			return View{}, fmt.Errorf("course: %w", err)
+		}
+		v.Course = course.Name
+	}
+
+	if data.DisciplineID != 0 {
+		disc, err := n.s.Discipline(ctx, data.DisciplineID)
+		if err != nil {
+			log.WarnContext(ctx, "failed to resolve discipline", slog.Int64("discipline_id", data.DisciplineID), sl.Err(err))
+		}
+		v.Discipline = disc.Name
+	}
+
+	if !data.ExamDate.IsZero() {
+		v.ExamDate = data.ExamDate.Format(dateLayout)
+	}
+
+	v.Grade = data.Grade
+
+	return v, nil
+}
+
+func fullName(u scheme.User) string {
+	return strings.TrimSpace(u.LastName + " " + u.FirstName + " " + u.Patronymic)
+}
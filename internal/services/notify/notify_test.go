@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+type fakeStorage struct {
+	disciplines map[int64]string
+	saved       []scheme.Notification
+}
+
+func (f *fakeStorage) User(_ context.Context, id int64) (scheme.User, error) {
+	return scheme.User{FirstName: "Ivan", LastName: "Petrov"}, nil
+}
+
+func (f *fakeStorage) UserEmail(_ context.Context, id int64) (string, error) {
+	return "user@example.com", nil
+}
+
+func (f *fakeStorage) NotificationPreferences(_ context.Context, id int64) (scheme.NotificationPreferences, error) {
+	return scheme.NotificationPreferences{Language: scheme.LangEN}, nil
+}
+
+func (f *fakeStorage) Course(_ context.Context, id int64) (scheme.Course, error) {
+	return scheme.Course{ID: id, Name: "CS-21"}, nil
+}
+
+func (f *fakeStorage) Discipline(_ context.Context, id int64) (scheme.Discipline, error) {
+	name, ok := f.disciplines[id]
+	if !ok {
+		return scheme.Discipline{}, sql.ErrNoRows
+	}
+	return scheme.Discipline{ID: id, Name: name}, nil
+}
+
+func (f *fakeStorage) SaveNotification(_ context.Context, n *scheme.Notification) (int64, error) {
+	f.saved = append(f.saved, *n)
+	return int64(len(f.saved)), nil
+}
+
+func TestNotifyRendersDiscipline(t *testing.T) {
+	tests := []struct {
+		name         string
+		disciplineID int64
+		subject      string
+		body         string
+	}{
+		{name: "found", disciplineID: 1, subject: "Exam grade: Physics", body: "Your Physics (CS-21) exam"},
+		{name: "missing", disciplineID: 2, subject: "Exam grade", body: "Your (CS-21) exam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeStorage{disciplines: map[int64]string{1: "Physics"}}
+			n := New(slog.New(slog.NewTextHandler(io.Discard, nil)), s)
+
+			n.Notify(context.Background(), scheme.NotifyExamGraded, 3, scheme.NotificationData{
+				CourseID:     1,
+				DisciplineID: tt.disciplineID,
+				ExamDate:     time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+				Grade:        5,
+			})
+
+			if len(s.saved) != 1 {
+				t.Fatalf("queued %d notifications, want 1", len(s.saved))
+			}
+			if got := s.saved[0].Subject; got != tt.subject {
+				t.Fatalf("subject = %q, want %q", got, tt.subject)
+			}
+			if got := s.saved[0].Body; !strings.Contains(got, tt.body) {
+				t.Fatalf("body = %q, want it to contain %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestRenderEveryKind(t *testing.T) {
+	v := View{Recipient: "Ivan", Student: "Petr", Course: "CS-21", Discipline: "Physics", ExamDate: "10.06.2024", Grade: 5}
+
+	for _, lang := range Languages() {
+		for _, kind := range Kinds() {
+			subject, body, err := Render(lang, kind, v)
+			if err != nil {
+				t.Fatalf("Render(%s, %s): %v", lang, kind, err)
+			}
+			if subject == "" || !strings.Contains(body, "Ivan") {
+				t.Fatalf("Render(%s, %s) = %q, %q", lang, kind, subject, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	batchSize  = 50
+	maxBackoff = 6 * time.Hour
+)
+
+type Outbox interface {
+	DueNotifications(context.Context, time.Time, int) ([]scheme.Notification, error)
+	NotificationSent(context.Context, int64, time.Time) error
+	NotificationFailed(context.Context, int64, string, time.Time) error
+}
+
+// Dispatcher sends the outbox, retrying failed emails with an exponential
+// backoff until MaxAttempts is reached
+type Dispatcher struct {
+	log         *slog.Logger
+	outbox      Outbox
+	sender      Sender
+	interval    time.Duration
+	maxAttempts int
+}
+
+func NewDispatcher(log *slog.Logger, outbox Outbox, sender Sender, interval time.Duration, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		log:         log.With(slog.String("component", "notify.dispatcher")),
+		outbox:      outbox,
+		sender:      sender,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run sends the outbox every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.Flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush makes one attempt to send every due email
+func (d *Dispatcher) Flush(ctx context.Context) {
+	due, err := d.outbox.DueNotifications(ctx, time.Now(), batchSize)
+	if err != nil {
+		d.log.Error("failed to get due notifications", sl.Err(err))
+		return
+	}
+
+	for _, n := range due {
+		d.send(ctx, n)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, n scheme.Notification) {
+	ctx, span := tracing.Start(ctx, "notify.Send",
+		attribute.Int64("notification_id", n.ID),
+		attribute.String("kind", n.Kind),
+	)
+	defer span.End()
+
+	log := d.log.With(
+		slog.Int64("notification_id", n.ID),
+		slog.String("kind", n.Kind),
+	)
+
+	sendErr := d.sender.Send(n.Email, n.Subject, n.Body)
+
+	// The email is gone, its outcome is recorded even on shutdown
+	ctx = context.WithoutCancel(ctx)
+
+	if sendErr == nil {
+		if err := d.outbox.NotificationSent(ctx, n.ID, time.Now()); err != nil {
+			log.ErrorContext(ctx, "failed to mark notification sent", sl.Err(err))
+		}
+		return
+	}
+
+	span.SetStatus(codes.Error, sendErr.Error())
+
+	attempts := n.Attempts + 1
+
+	var next time.Time
+	if attempts < d.maxAttempts {
+		next = time.Now().Add(backoff(attempts))
+	}
+
+	if err := d.outbox.NotificationFailed(ctx, n.ID, sendErr.Error(), next); err != nil {
+		log.ErrorContext(ctx, "failed to record notification failure", sl.Err(err))
+	}
+
+	if next.IsZero() {
+		log.ErrorContext(ctx, "notification given up", slog.Int("attempts", attempts), sl.Err(sendErr))
+	} else {
+		log.WarnContext(ctx, "failed to send notification", slog.Int("attempts", attempts), slog.Time("retry_at", next), sl.Err(sendErr))
+	}
+}
+
+// backoff doubles the delay from a minute with every failed attempt
+func backoff(attempts int) time.Duration {
+	d := time.Minute << (attempts - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+// message is the subject and body template of a notification in one language
+type message struct {
+	subject string
+	body    string
+}
+
+// View is what the templates are rendered with
+type View struct {
+	Recipient  string
+	Student    string
+	Course     string
+	Discipline string
+	ExamDate   string
+	Grade      int
+}
+
+var messages = map[string]map[string]message{
+	scheme.LangRU: {
+		scheme.NotifyExamSignUp: {
+			subject: "Запись на экзамен{{with .Discipline}}: {{.}}{{end}}",
+			body: `Здравствуйте, {{.Recipient}}!
+
+{{.Student}} записался(-ась) на экзамен{{with .Discipline}} по дисциплине «{{.}}»{{end}} ({{.Course}}) на {{.ExamDate}}.
+`,
+		},
+		scheme.NotifyExamGraded: {
+			subject: "Оценка за экзамен{{with .Discipline}}: {{.}}{{end}}",
+			body: `Здравствуйте, {{.Recipient}}!
+
+За экзамен{{with .Discipline}} по дисциплине «{{.}}»{{end}} ({{.Course}}) от {{.ExamDate}} выставлена оценка {{.Grade}}.
+`,
+		},
+		scheme.NotifyExamRescheduled: {
+			subject: "Перенос экзамена{{with .Discipline}}: {{.}}{{end}}",
+			body: `Здравствуйте, {{.Recipient}}!
+
+Экзамен{{with .Discipline}} по дисциплине «{{.}}»{{end}} ({{.Course}}), на который вы записаны, перенесён на {{.ExamDate}}.
+`,
+		},
+		scheme.NotifyEnrolled: {
+			subject: "Зачисление на курс {{.Course}}",
+			body: `Здравствуйте, {{.Recipient}}!
+
+Вы зачислены на курс «{{.Course}}».
+`,
+		},
+		scheme.NotifyUnenrolled: {
+			subject: "Отчисление с курса {{.Course}}",
+			body: `Здравствуйте, {{.Recipient}}!
+
+Вы отчислены с курса «{{.Course}}».
+`,
+		},
+	},
+	scheme.LangEN: {
+		scheme.NotifyExamSignUp: {
+			subject: "Exam registration{{with .Discipline}}: {{.}}{{end}}",
+			body: `Hello {{.Recipient}},
+
+{{.Student}} has registered for the {{with .Discipline}}{{.}} {{end}}({{.Course}}) exam on {{.ExamDate}}.
+`,
+		},
+		scheme.NotifyExamGraded: {
+			subject: "Exam grade{{with .Discipline}}: {{.}}{{end}}",
+			body: `Hello {{.Recipient}},
+
+Your {{with .Discipline}}{{.}} {{end}}({{.Course}}) exam of {{.ExamDate}} has been graded {{.Grade}}.
+`,
+		},
+		scheme.NotifyExamRescheduled: {
+			subject: "Exam rescheduled{{with .Discipline}}: {{.}}{{end}}",
+			body: `Hello {{.Recipient}},
+
+The {{with .Discipline}}{{.}} {{end}}({{.Course}}) exam you registered for has been moved to {{.ExamDate}}.
+`,
+		},
+		scheme.NotifyEnrolled: {
+			subject: "Enrolled in {{.Course}}",
+			body: `Hello {{.Recipient}},
+
+You have been enrolled in the course {{.Course}}.
+`,
+		},
+		scheme.NotifyUnenrolled: {
+			subject: "Removed from {{.Course}}",
+			body: `Hello {{.Recipient}},
+
+You have been removed from the course {{.Course}}.
+`,
+		},
+	},
+}
+
+// templates are parsed once, keyed by language and kind
+var templates = parse()
+
+type parsed struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func parse() map[string]map[string]parsed {
+	res := make(map[string]map[string]parsed)
+
+	for lang, kinds := range messages {
+		res[lang] = make(map[string]parsed)
+		for kind, m := range kinds {
+			name := lang + "/" + kind
+			res[lang][kind] = parsed{
+				subject: template.Must(template.New(name + "/subject").Parse(m.subject)),
+				body:    template.Must(template.New(name + "/body").Parse(m.body)),
+			}
+		}
+	}
+
+	return res
+}
+
+// Languages lists the languages notifications can be sent in
+func Languages() []string {
+	return []string{scheme.LangRU, scheme.LangEN}
+}
+
+// Kinds lists the kinds of notifications
+func Kinds() []string {
+	return []string{scheme.NotifyExamSignUp, scheme.NotifyExamGraded, scheme.NotifyExamRescheduled, scheme.NotifyEnrolled, scheme.NotifyUnenrolled}
+}
+
+// Render renders the subject and body of the notification, falling back to
+// Russian for an unknown language
+func Render(lang, kind string, v View) (subject, body string, err error) {
+	kinds, ok := templates[lang]
+	if !ok {
+		kinds = templates[scheme.LangRU]
+	}
+
+	t, ok := kinds[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification kind %q", kind)
+	}
+
+	var b bytes.Buffer
+
+	if err := t.subject.Execute(&b, v); err != nil {
+		return "", "", err
+	}
+	subject = b.String()
+
+	b.Reset()
+	if err := t.body.Execute(&b, v); err != nil {
+		return "", "", err
+	}
+
+	return subject, b.String(), nil
+}
@@ -1,6 +1,10 @@
 package policy
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/arxonic/journal/internal/domain/models"
+)
 
 // "/": ["admin", "student", "teacher"]
 type AccessControl struct {
@@ -19,11 +23,54 @@ func (ac *AccessControl) Add(url string, roles ...string) {
 	ac.List[url] = roles
 }
 
-func (ac *AccessControl) Contains(k, v string) bool {
-	for _, r := range ac.List[k] {
-		if r == v {
+// Role returns the role the key acts as on the url.
+// It is the active role if the client selected one, otherwise the first role
+// of the url list the user holds. Empty if the url is not allowed.
+func (ac *AccessControl) Role(url string, key *models.Key) string {
+	for _, r := range ac.List[url] {
+		if key.Active != "" && key.Active != r {
+			continue
+		}
+		if key.Has(r) {
+			return r
+		}
+	}
+	return ""
+}
+
+func (ac *AccessControl) Contains(url string, key *models.Key) bool {
+	return ac.Role(url, key) != ""
+}
+
+// Permits checks the role like Contains and limits scoped roles to their subtree.
+// path is the chain of org unit IDs from the target unit up to the root,
+// nil means the target is institute-wide and only unscoped roles may touch it.
+func (ac *AccessControl) Permits(url string, key *models.Key, path []int64) bool {
+	role := ac.Role(url, key)
+	if role == "" {
+		return false
+	}
+
+	for _, scope := range key.Scopes(role) {
+		if scope == 0 {
 			return true
 		}
+		for _, id := range path {
+			if id == scope {
+				return true
+			}
+		}
 	}
 	return false
 }
+
+// Scopes returns the org units the key acts in on the url, nil if it acts institute-wide
+func (ac *AccessControl) Scopes(url string, key *models.Key) []int64 {
+	scopes := key.Scopes(ac.Role(url, key))
+	for _, scope := range scopes {
+		if scope == 0 {
+			return nil
+		}
+	}
+	return scopes
+}
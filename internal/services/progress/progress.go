@@ -0,0 +1,46 @@
+package progress
+
+import "github.com/arxonic/journal/internal/domain/scheme"
+
+// Compute matches the student's latest grades against the curriculum.
+// The curriculum is completed when every required item is passed and
+// enough elective credits are earned.
+func Compute(studentID int64, c scheme.Curriculum, grades map[int64]scheme.Grade) scheme.Progress {
+	p := scheme.Progress{
+		StudentID:       studentID,
+		CurriculumID:    c.ID,
+		ElectiveCredits: c.ElectiveCredits,
+		Items:           make([]scheme.ItemProgress, 0, len(c.Items)),
+	}
+
+	requiredPassed := true
+
+	for _, item := range c.Items {
+		ip := scheme.ItemProgress{CurriculumItem: item}
+
+		if grade, ok := grades[item.DisciplineID]; ok {
+			ip.Grade = &grade
+			ip.Passed = grade.Passed
+		}
+
+		switch item.Kind {
+		case scheme.ItemRequired:
+			p.RequiredCredits += item.CreditHours
+			if ip.Passed {
+				p.EarnedRequiredCredits += item.CreditHours
+			} else {
+				requiredPassed = false
+			}
+		case scheme.ItemElective:
+			if ip.Passed {
+				p.EarnedElectiveCredits += item.CreditHours
+			}
+		}
+
+		p.Items = append(p.Items, ip)
+	}
+
+	p.Completed = requiredPassed && p.EarnedElectiveCredits >= p.ElectiveCredits
+
+	return p
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store keeps the buckets. MemoryStore suits a single instance, several
+// instances behind a balancer share an external one.
+type Store interface {
+	// Take removes n tokens from the bucket of key if it holds them and
+	// otherwise tells how long until it will
+	Take(key string, l Limit, n float64, now time.Time) (ok bool, retryAfter time.Duration)
+}
+
+// Limiter applies one limit to the buckets of its name in the store
+type Limiter struct {
+	name  string
+	limit Limit
+	store Store
+}
+
+// New returns a limiter of the named limit, a zero rate disables it
+func New(name string, l Limit, store Store) *Limiter {
+	l.Burst = max(l.Burst, 1)
+
+	return &Limiter{
+		name:  name,
+		limit: l,
+		store: store,
+	}
+}
+
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+// Allow takes a token for key
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	return l.store.Take(l.name+":"+key, l.limit, 1, time.Now())
+}
+
+// Ready tells whether key has a token left without taking it
+func (l *Limiter) Ready(key string) (bool, time.Duration) {
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+	return l.store.Take(l.name+":"+key, l.limit, 0, time.Now())
+}
+
+// Buckets refilled to the brim are dropped every sweepInterval
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore keeps the buckets in the process
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Take(key string, l Limit, n float64, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now, limit: l}
+		s.buckets[key] = b
+	}
+
+	b.refill(now)
+
+	// A zero n still fails on an empty bucket
+	if b.tokens >= math.Max(n, 1) {
+		b.tokens -= n
+		return true, 0
+	}
+
+	wait := (math.Max(n, 1) - b.tokens) / l.Rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	}
+	b.last = now
+}
+
+// sweep drops the buckets back to their burst, they are recreated full
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+
+	type take struct {
+		after     time.Duration
+		n         float64
+		wantOK    bool
+		wantRetry time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "burst",
+			takes: []take{
+				{n: 1, wantOK: true},
+				{n: 1, wantOK: true},
+				{n: 1, wantOK: true},
+				{n: 1, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "retry after shrinks",
+			takes: []take{
+				{n: 3, wantOK: true},
+				{after: 200 * time.Millisecond, n: 1, wantRetry: 300 * time.Millisecond},
+				{after: 300 * time.Millisecond, n: 1, wantOK: true},
+			},
+		},
+		{
+			name: "refill",
+			takes: []take{
+				{n: 3, wantOK: true},
+				{after: time.Second, n: 2, wantOK: true},
+				{n: 1, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill stops at burst",
+			takes: []take{
+				{n: 3, wantOK: true},
+				{after: time.Hour, n: 3, wantOK: true},
+				{n: 1, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "ready does not take",
+			takes: []take{
+				{n: 0, wantOK: true},
+				{n: 3, wantOK: true},
+				{n: 0, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "failed take keeps tokens",
+			takes: []take{
+				{n: 2, wantOK: true},
+				{n: 2, wantRetry: 500 * time.Millisecond},
+				{n: 1, wantOK: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			now := s.lastSweep
+
+			for i, tk := range tt.takes {
+				now = now.Add(tk.after)
+
+				ok, retry := s.Take("key", limit, tk.n, now)
+				if ok != tk.wantOK || retry != tk.wantRetry {
+					t.Fatalf("take %d = %v, %v, want %v, %v", i, ok, retry, tk.wantOK, tk.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeys(t *testing.T) {
+	s := NewMemoryStore()
+	now := s.lastSweep
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if ok, _ := s.Take("a", limit, 1, now); !ok {
+		t.Fatal("first take of a failed")
+	}
+	if ok, _ := s.Take("b", limit, 1, now); !ok {
+		t.Fatal("bucket of b is shared with a")
+	}
+	if ok, _ := s.Take("a", limit, 1, now); ok {
+		t.Fatal("second take of a passed")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s := NewMemoryStore()
+	now := s.lastSweep
+
+	s.Take("full", Limit{Rate: 1, Burst: 2}, 1, now)
+	s.Take("slow", Limit{Rate: 0.01, Burst: 2}, 2, now)
+
+	// Before the interval nothing is dropped
+	now = now.Add(sweepInterval / 2)
+	s.Take("other", Limit{Rate: 1, Burst: 1}, 0, now)
+	if len(s.buckets) != 3 {
+		t.Fatalf("%d buckets before the sweep, want 3", len(s.buckets))
+	}
+
+	now = now.Add(sweepInterval / 2)
+	s.Take("trigger", Limit{Rate: 1, Burst: 1}, 1, now)
+
+	if _, ok := s.buckets["full"]; ok {
+		t.Error("refilled bucket was kept")
+	}
+	if _, ok := s.buckets["other"]; ok {
+		t.Error("untouched bucket was kept")
+	}
+	if _, ok := s.buckets["slow"]; !ok {
+		t.Error("bucket still refilling was dropped")
+	}
+	if !s.lastSweep.Equal(now) {
+		t.Errorf("last sweep %v, want %v", s.lastSweep, now)
+	}
+
+	// The dropped bucket comes back full
+	if ok, _ := s.Take("full", Limit{Rate: 1, Burst: 2}, 2, now); !ok {
+		t.Error("recreated bucket is not full")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New("off", Limit{}, NewMemoryStore())
+
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("key"); !ok {
+			t.Fatalf("request %d limited by a zero rate", i)
+		}
+	}
+}
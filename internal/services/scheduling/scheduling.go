@@ -0,0 +1,108 @@
+package scheduling
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/services/calendar"
+)
+
+const dateLayout = "2006-01-02"
+
+// ExamConflicts checks a new exam of a student against the exams the student
+// is already registered for and the institute calendar of the exam's academic
+// year: the exam must fall within an exam session and not on a holiday, and
+// the registration must happen within a registration window. Sessions and
+// windows are only enforced once the calendar has them.
+// An empty result means the exam can be booked.
+func ExamConflicts(date, now time.Time, booked []scheme.ExamEvent, events []scheme.AcademicEvent, rules scheme.ExamRules) []scheme.ExamConflict {
+	conflicts := make([]scheme.ExamConflict, 0)
+
+	day := dateOf(date)
+
+	for _, h := range calendar.At(events, scheme.EventHoliday, date) {
+		conflicts = append(conflicts, scheme.ExamConflict{
+			Kind:    scheme.ConflictHoliday,
+			Message: fmt.Sprintf("%s is a holiday: %s", day.Format(dateLayout), h.Name),
+		})
+	}
+
+	if ok, _ := calendar.Allowed(events, scheme.EventExamSession, date); !ok {
+		conflicts = append(conflicts, scheme.ExamConflict{
+			Kind:    scheme.ConflictOutsideSession,
+			Message: fmt.Sprintf("%s is outside of the exam session %s", day.Format(dateLayout), windows(events, scheme.EventExamSession)),
+		})
+	}
+
+	if ok, _ := calendar.Allowed(events, scheme.EventRegistration, now); !ok {
+		conflicts = append(conflicts, scheme.ExamConflict{
+			Kind:    scheme.ConflictRegistrationClosed,
+			Message: fmt.Sprintf("exam registration is closed, it is open %s", windows(events, scheme.EventRegistration)),
+		})
+	}
+
+	start, end := examInterval(date, rules)
+
+	for _, e := range booked {
+		examDate := e.ExamDate
+
+		otherStart, otherEnd := examInterval(examDate, rules)
+
+		if start.Before(otherEnd) && otherStart.Before(end) {
+			conflicts = append(conflicts, scheme.ExamConflict{
+				Kind:         scheme.ConflictExamOverlap,
+				AssignmentID: e.AssignmentID,
+				ExamDate:     &examDate,
+				Message:      fmt.Sprintf("overlaps the %s exam on %s", e.DisciplineName, examDate.Format(time.DateTime)),
+			})
+			continue
+		}
+
+		if rules.MinGapDays > 0 {
+			gap := daysBetween(day, dateOf(examDate))
+			if gap < rules.MinGapDays {
+				conflicts = append(conflicts, scheme.ExamConflict{
+					Kind:         scheme.ConflictExamGap,
+					AssignmentID: e.AssignmentID,
+					ExamDate:     &examDate,
+					Message: fmt.Sprintf("only %d day(s) apart from the %s exam on %s, at least %d required",
+						gap, e.DisciplineName, examDate.Format(dateLayout), rules.MinGapDays),
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// examInterval returns the time the exam takes, the whole day if it has no time of day
+func examInterval(date time.Time, rules scheme.ExamRules) (time.Time, time.Time) {
+	if date.Equal(dateOf(date)) {
+		return date, date.AddDate(0, 0, 1)
+	}
+	return date, date.Add(time.Duration(rules.DurationMinutes) * time.Minute)
+}
+
+// windows lists the date ranges of the events of the kind
+func windows(events []scheme.AcademicEvent, kind string) string {
+	var ranges []string
+	for _, e := range calendar.Filter(events, kind) {
+		ranges = append(ranges, e.DateFrom.Format(dateLayout)+" - "+e.DateTo.Format(dateLayout))
+	}
+	return strings.Join(ranges, ", ")
+}
+
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	days := int(b.Sub(a).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
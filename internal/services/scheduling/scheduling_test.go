@@ -0,0 +1,141 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+func at(s string) time.Time {
+	layout := time.DateTime
+	if len(s) == len(dateLayout) {
+		layout = dateLayout
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func event(kind, from, to string) scheme.AcademicEvent {
+	return scheme.AcademicEvent{Kind: kind, Name: kind, DateFrom: at(from), DateTo: at(to)}
+}
+
+func booked(date string) scheme.ExamEvent {
+	return scheme.ExamEvent{AssignmentID: 7, DisciplineName: "Physics", ExamDate: at(date)}
+}
+
+func TestExamConflicts(t *testing.T) {
+	rules := scheme.ExamRules{MinGapDays: 2, DurationMinutes: 90}
+	now := at("2024-05-20")
+
+	calendar := []scheme.AcademicEvent{
+		event(scheme.EventExamSession, "2024-06-01", "2024-06-30"),
+		event(scheme.EventRegistration, "2024-05-15", "2024-05-31"),
+		event(scheme.EventHoliday, "2024-06-12", "2024-06-12"),
+	}
+
+	tests := []struct {
+		name   string
+		date   string
+		now    time.Time
+		booked []scheme.ExamEvent
+		events []scheme.AcademicEvent
+		rules  scheme.ExamRules
+		want   []string
+	}{
+		{name: "free", date: "2024-06-10", events: calendar, rules: rules},
+		{name: "no calendar", date: "2024-01-10", rules: rules},
+		{name: "holiday", date: "2024-06-12", events: calendar, rules: rules, want: []string{scheme.ConflictHoliday}},
+		{name: "outside session", date: "2024-07-01", events: calendar, rules: rules, want: []string{scheme.ConflictOutsideSession}},
+		{
+			name:   "registration closed",
+			date:   "2024-06-10",
+			now:    at("2024-06-01"),
+			events: calendar,
+			rules:  rules,
+			want:   []string{scheme.ConflictRegistrationClosed},
+		},
+		{
+			name:   "same day",
+			date:   "2024-06-10",
+			booked: []scheme.ExamEvent{booked("2024-06-10")},
+			rules:  rules,
+			want:   []string{scheme.ConflictExamOverlap},
+		},
+		{
+			name:   "timed exams overlap",
+			date:   "2024-06-10 10:00:00",
+			booked: []scheme.ExamEvent{booked("2024-06-10 11:00:00")},
+			rules:  rules,
+			want:   []string{scheme.ConflictExamOverlap},
+		},
+		{
+			name:   "timed exams back to back",
+			date:   "2024-06-10 10:00:00",
+			booked: []scheme.ExamEvent{booked("2024-06-10 11:30:00")},
+			rules:  scheme.ExamRules{DurationMinutes: 90},
+		},
+		{
+			name:   "too close",
+			date:   "2024-06-10",
+			booked: []scheme.ExamEvent{booked("2024-06-11")},
+			rules:  rules,
+			want:   []string{scheme.ConflictExamGap},
+		},
+		{
+			name:   "too close before",
+			date:   "2024-06-10",
+			booked: []scheme.ExamEvent{booked("2024-06-09 12:00:00")},
+			rules:  rules,
+			want:   []string{scheme.ConflictExamGap},
+		},
+		{
+			name:   "gap kept",
+			date:   "2024-06-10",
+			booked: []scheme.ExamEvent{booked("2024-06-12")},
+			rules:  rules,
+		},
+		{
+			name:   "gap disabled",
+			date:   "2024-06-10",
+			booked: []scheme.ExamEvent{booked("2024-06-11")},
+			rules:  scheme.ExamRules{DurationMinutes: 90},
+		},
+		{
+			name:   "several",
+			date:   "2024-06-12",
+			booked: []scheme.ExamEvent{booked("2024-06-12"), booked("2024-06-13")},
+			events: calendar,
+			rules:  rules,
+			want:   []string{scheme.ConflictHoliday, scheme.ConflictExamOverlap, scheme.ConflictExamGap},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := now
+			if !tt.now.IsZero() {
+				clock = tt.now
+			}
+
+			conflicts := ExamConflicts(at(tt.date), clock, tt.booked, tt.events, tt.rules)
+
+			kinds := make([]string, 0, len(conflicts))
+			for _, c := range conflicts {
+				kinds = append(kinds, c.Kind)
+			}
+
+			if len(kinds) != len(tt.want) {
+				t.Fatalf("conflicts = %v, want %v", kinds, tt.want)
+			}
+			for i := range tt.want {
+				if kinds[i] != tt.want[i] {
+					t.Fatalf("conflicts = %v, want %v", kinds, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package timetable
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+const timeLayout = "15:04"
+
+var (
+	ErrInvalidLesson = errors.New("invalid lesson")
+)
+
+// Validate checks the weekday, the times and the week parity and normalizes the parity
+func Validate(lesson *scheme.Lesson) error {
+	if lesson.Weekday < 1 || lesson.Weekday > 7 {
+		return fmt.Errorf("%w: weekday must be 1-7", ErrInvalidLesson)
+	}
+
+	start, err := time.Parse(timeLayout, lesson.StartTime)
+	if err != nil {
+		return fmt.Errorf("%w: start_time must be HH:MM", ErrInvalidLesson)
+	}
+
+	end, err := time.Parse(timeLayout, lesson.EndTime)
+	if err != nil {
+		return fmt.Errorf("%w: end_time must be HH:MM", ErrInvalidLesson)
+	}
+
+	if !start.Before(end) {
+		return fmt.Errorf("%w: lesson must end after it starts", ErrInvalidLesson)
+	}
+
+	// Store times zero-padded so that they compare as strings
+	lesson.StartTime = start.Format(timeLayout)
+	lesson.EndTime = end.Format(timeLayout)
+
+	switch lesson.WeekParity {
+	case "":
+		lesson.WeekParity = scheme.WeekEvery
+	case scheme.WeekEvery, scheme.WeekOdd, scheme.WeekEven:
+	default:
+		return fmt.Errorf("%w: week_parity must be every, odd or even", ErrInvalidLesson)
+	}
+
+	return nil
+}
+
+// Overlap reports whether two lessons can be held at the same time
+func Overlap(a, b scheme.Lesson) bool {
+	if a.Weekday != b.Weekday {
+		return false
+	}
+
+	if a.WeekParity != scheme.WeekEvery && b.WeekParity != scheme.WeekEvery && a.WeekParity != b.WeekParity {
+		return false
+	}
+
+	return a.StartTime < b.EndTime && b.StartTime < a.EndTime
+}
+
+// Conflicts returns the double-bookings of the teacher, the group and the room
+// the new lesson would cause, and whether the group fits into the room.
+func Conflicts(lesson scheme.Lesson, existing []scheme.Lesson, room scheme.Room, groupSize int) []scheme.LessonConflict {
+	conflicts := make([]scheme.LessonConflict, 0)
+
+	for _, other := range existing {
+		if other.ID == lesson.ID || !Overlap(lesson, other) {
+			continue
+		}
+
+		when := fmt.Sprintf("%s-%s", other.StartTime, other.EndTime)
+
+		if other.TeacherID == lesson.TeacherID {
+			conflicts = append(conflicts, scheme.LessonConflict{
+				Kind:     scheme.ConflictTeacher,
+				LessonID: other.ID,
+				Message:  fmt.Sprintf("teacher %d already has lesson %d at %s", lesson.TeacherID, other.ID, when),
+			})
+		}
+
+		if other.CourseID == lesson.CourseID {
+			conflicts = append(conflicts, scheme.LessonConflict{
+				Kind:     scheme.ConflictGroup,
+				LessonID: other.ID,
+				Message:  fmt.Sprintf("course %d already has lesson %d at %s", lesson.CourseID, other.ID, when),
+			})
+		}
+
+		if other.RoomID == lesson.RoomID {
+			conflicts = append(conflicts, scheme.LessonConflict{
+				Kind:     scheme.ConflictRoom,
+				LessonID: other.ID,
+				Message:  fmt.Sprintf("room %s is taken by lesson %d at %s", room.Name, other.ID, when),
+			})
+		}
+	}
+
+	if groupSize > room.Capacity {
+		conflicts = append(conflicts, scheme.LessonConflict{
+			Kind:    scheme.ConflictCapacity,
+			Message: fmt.Sprintf("room %s seats %d, the course has %d students", room.Name, room.Capacity, groupSize),
+		})
+	}
+
+	return conflicts
+}
@@ -0,0 +1,231 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	HeaderEvent     = "X-Journal-Event"
+	HeaderDelivery  = "X-Journal-Delivery"
+	HeaderTimestamp = "X-Journal-Timestamp"
+	HeaderSignature = "X-Journal-Signature"
+
+	secretBytes = 32
+	batchSize   = 50
+	maxBackoff  = 12 * time.Hour
+	// Response bodies are only kept for the delivery log
+	maxErrorBody = 512
+)
+
+// Topics lists the domain events webhooks can subscribe to
+func Topics() []string {
+	return []string{
+		scheme.TopicCourseCreated,
+		scheme.TopicStudentEnrolled,
+		scheme.TopicStudentUnenrolled,
+		scheme.TopicExamSignedUp,
+		scheme.TopicGradePosted,
+	}
+}
+
+// NewSecret returns a random hex key for signing the payloads of a webhook
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value of the payload sent at timestamp:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers recompute it with the webhook secret and compare in constant time.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type Outbox interface {
+	DispatchEvents(context.Context, int) (int, error)
+	DueWebhookDeliveries(context.Context, time.Time, int) ([]scheme.PendingDelivery, error)
+	WebhookDelivered(context.Context, int64, int, time.Time) error
+	WebhookDeliveryFailed(context.Context, int64, int, string, time.Time) error
+}
+
+// Dispatcher fans the domain events of the outbox out to the webhooks and
+// delivers them, retrying failures with an exponential backoff until
+// maxAttempts is reached
+type Dispatcher struct {
+	log         *slog.Logger
+	outbox      Outbox
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+}
+
+func NewDispatcher(log *slog.Logger, outbox Outbox, client *http.Client, interval time.Duration, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		log:         log.With(slog.String("component", "webhooks.dispatcher")),
+		outbox:      outbox,
+		client:      client,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run delivers the outbox every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.Flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush fans out the new events and makes one attempt to send every due delivery
+func (d *Dispatcher) Flush(ctx context.Context) {
+	for {
+		n, err := d.outbox.DispatchEvents(ctx, batchSize)
+		if err != nil {
+			d.log.Error("failed to dispatch domain events", sl.Err(err))
+			return
+		}
+		if n < batchSize {
+			break
+		}
+	}
+
+	due, err := d.outbox.DueWebhookDeliveries(ctx, time.Now(), batchSize)
+	if err != nil {
+		d.log.Error("failed to get due deliveries", sl.Err(err))
+		return
+	}
+
+	for _, p := range due {
+		d.deliver(ctx, p)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, p scheme.PendingDelivery) {
+	ctx, span := tracing.Start(ctx, "webhooks.Deliver",
+		attribute.Int64("delivery_id", p.ID),
+		attribute.Int64("webhook_id", p.WebhookID),
+		attribute.String("topic", p.Event.Topic),
+	)
+	defer span.End()
+
+	log := d.log.With(
+		slog.Int64("delivery_id", p.ID),
+		slog.Int64("webhook_id", p.WebhookID),
+		slog.String("topic", p.Event.Topic),
+	)
+
+	code, sendErr := d.send(ctx, p)
+
+	// The outcome is recorded even on shutdown, else a delivered event is sent again
+	ctx = context.WithoutCancel(ctx)
+
+	if sendErr == nil {
+		if err := d.outbox.WebhookDelivered(ctx, p.ID, code, time.Now()); err != nil {
+			log.ErrorContext(ctx, "failed to mark delivery delivered", sl.Err(err))
+		}
+		return
+	}
+
+	span.SetStatus(codes.Error, sendErr.Error())
+
+	attempts := p.Attempts + 1
+
+	var next time.Time
+	if attempts < d.maxAttempts {
+		next = time.Now().Add(backoff(attempts))
+	}
+
+	if err := d.outbox.WebhookDeliveryFailed(ctx, p.ID, code, sendErr.Error(), next); err != nil {
+		log.ErrorContext(ctx, "failed to record delivery failure", sl.Err(err))
+	}
+
+	if next.IsZero() {
+		log.ErrorContext(ctx, "webhook delivery given up", slog.Int("attempts", attempts), sl.Err(sendErr))
+	} else {
+		log.WarnContext(ctx, "failed to deliver webhook", slog.Int("attempts", attempts), slog.Time("retry_at", next), sl.Err(sendErr))
+	}
+}
+
+// send posts the event, any 2xx response counts as delivered
+func (d *Dispatcher) send(ctx context.Context, p scheme.PendingDelivery) (int, error) {
+	body, err := json.Marshal(p.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.Event.Topic)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(p.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(p.Secret, timestamp, body))
+	// Receivers may continue the trace of the delivery
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		if msg = bytes.TrimSpace(msg); len(msg) > 0 {
+			return res.StatusCode, fmt.Errorf("unexpected status %s: %s", res.Status, msg)
+		}
+		return res.StatusCode, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// backoff doubles the delay from a minute with every failed attempt
+func backoff(attempts int) time.Duration {
+	d := time.Minute << (attempts - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,51 @@
+package webhooks
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"grade.posted"}`)
+
+	// Expected values computed independently with Python's hmac module
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      string
+	}{
+		{
+			name:      "payload",
+			secret:    "secret",
+			timestamp: 1700000000,
+			body:      body,
+			want:      "sha256=4bd015731a654bdd652b06ba3e830b187748f937e3ab88f239feb4f48089248c",
+		},
+		{
+			name:      "other timestamp",
+			secret:    "secret",
+			timestamp: 1700000001,
+			body:      body,
+			want:      "sha256=2d4f26018b18b75c6ee461013be7856c41483043f1f5cff4e41d5eb1befb61e8",
+		},
+		{
+			name:      "other secret",
+			secret:    "other",
+			timestamp: 1700000000,
+			body:      body,
+			want:      "sha256=b6ff59effaa6cf35ae7fb05b860a9ebd9c659fce7cfa0a795a7a2e32ca9103d1",
+		},
+		{
+			name:   "empty body",
+			secret: "secret",
+			want:   "sha256=3445798a051818ef95def46c2eb62b43d377ce6e3c29b4d0aec3da0e59577f79",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, tt.body); got != tt.want {
+				t.Fatalf("Sign = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
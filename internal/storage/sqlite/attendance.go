@@ -0,0 +1,437 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+const (
+	settingAttendanceThreshold = "attendance_threshold"
+	defaultAttendanceThreshold = 25
+)
+
+func (s *Storage) SaveSession(ctx context.Context, session *scheme.ClassSession) (int64, error) {
+	const fn = "storage.sqlite.SaveSession"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, session.AssignmentID)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return 0, store.ErrPeriodArchived
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO class_sessions (assignment_id, session_date, topic) VALUES (?, ?, ?)",
+		session.AssignmentID, session.SessionDate, session.Topic)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) Sessions(ctx context.Context, assignmentID int64) (scheme.ClassSessions, error) {
+	const fn = "storage.sqlite.Sessions"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, session_date, topic FROM class_sessions WHERE assignment_id = ? ORDER BY session_date, id", assignmentID)
+	if err != nil {
+		return scheme.ClassSessions{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	sessions := scheme.ClassSessions{Sessions: make([]scheme.ClassSession, 0)}
+
+	for rows.Next() {
+		var session scheme.ClassSession
+		var topic sql.NullString
+		if err := rows.Scan(&session.ID, &session.SessionDate, &topic); err != nil {
+			return scheme.ClassSessions{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		session.AssignmentID = assignmentID
+		session.Topic = topic.String
+
+		sessions.Sessions = append(sessions.Sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *Storage) Session(ctx context.Context, sessionID int64) (scheme.ClassSession, error) {
+	const fn = "storage.sqlite.Session"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var session scheme.ClassSession
+	var topic sql.NullString
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, assignment_id, session_date, topic FROM class_sessions WHERE id = ?", sessionID).
+		Scan(&session.ID, &session.AssignmentID, &session.SessionDate, &topic)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.ClassSession{}, store.ErrSessionNotFound
+		}
+		return scheme.ClassSession{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	session.Topic = topic.String
+
+	return session, nil
+}
+
+// Mark the attendance of the session, existing marks are overwritten
+func (s *Storage) MarkAttendance(ctx context.Context, sessionID, teacherID int64, marks []scheme.AttendanceMark) error {
+	const fn = "storage.sqlite.MarkAttendance"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	session, err := s.Session(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	archived, err := s.assignmentArchived(ctx, session.AssignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	for _, mark := range marks {
+		var n int
+
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM enrollments e
+			JOIN assignments a ON a.course_id = e.course_id
+			WHERE a.id = ? AND e.student_id = ?`, session.AssignmentID, mark.StudentID).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+		if n == 0 {
+			return store.ErrNotEnrolled
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO attendance (session_id, student_id, teacher_id, status) VALUES (?, ?, ?, ?)
+			ON CONFLICT(session_id, student_id) DO UPDATE SET
+				teacher_id = excluded.teacher_id, status = excluded.status`,
+			sessionID, mark.StudentID, teacherID, mark.Status)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the attendance per student and assignment
+func (s *Storage) Attendance(ctx context.Context, filter scheme.AttendanceFilter) (scheme.AttendanceReport, error) {
+	const fn = "storage.sqlite.Attendance"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT at.student_id, a.course_id, a.id, a.discipline_id,
+			SUM(at.status = 'present'), SUM(at.status = 'absent'), SUM(at.status = 'excused'), SUM(at.status = 'late'), COUNT(*)
+		FROM attendance at
+		JOIN class_sessions cs ON cs.id = at.session_id
+		JOIN assignments a ON a.id = cs.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		WHERE (? = 0 OR at.student_id = ?)
+		AND (? = 0 OR a.course_id = ?)
+		AND (? = 0 OR a.teacher_id = ?)
+		AND (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		AND (? = 0 OR a.semester_id = ?)
+		GROUP BY at.student_id, a.id
+		ORDER BY a.course_id, at.student_id, a.id`,
+		filter.StudentID, filter.StudentID,
+		filter.CourseID, filter.CourseID,
+		filter.TeacherID, filter.TeacherID,
+		filter.Period.AcademicYearID, filter.Period.AcademicYearID,
+		filter.Period.SemesterID, filter.Period.SemesterID,
+	)
+	if err != nil {
+		return scheme.AttendanceReport{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	report := scheme.AttendanceReport{Stats: make([]scheme.AttendanceStat, 0)}
+
+	for rows.Next() {
+		var st scheme.AttendanceStat
+		err := rows.Scan(&st.StudentID, &st.CourseID, &st.AssignmentID, &st.DisciplineID,
+			&st.Present, &st.Absent, &st.Excused, &st.Late, &st.Total)
+		if err != nil {
+			return scheme.AttendanceReport{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		st.AbsenceRate = absenceRate(st.Absent, st.Total)
+
+		report.Stats = append(report.Stats, st)
+	}
+
+	return report, rows.Err()
+}
+
+// Get the students whose unexcused absences in a course exceed the threshold.
+// Only courses of the subtrees rooted at scopes are checked if there are any.
+func (s *Storage) AttendanceAlerts(ctx context.Context, scopes []int64, period scheme.Period, threshold float64) ([]scheme.AttendanceAlert, error) {
+	const fn = "storage.sqlite.AttendanceAlerts"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var prefix, unitFilter string
+	var args []any
+
+	if len(scopes) > 0 {
+		prefix, args = subtreeQuery(scopes)
+		unitFilter = "AND c.unit_id IN (SELECT id FROM subtree)"
+	}
+
+	args = append(args,
+		period.AcademicYearID, period.AcademicYearID,
+		period.SemesterID, period.SemesterID,
+		threshold,
+	)
+
+	rows, err := s.db.QueryContext(ctx, prefix+` SELECT at.student_id, a.course_id, SUM(at.status = 'absent'), COUNT(*)
+		FROM attendance at
+		JOIN class_sessions cs ON cs.id = at.session_id
+		JOIN assignments a ON a.id = cs.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		WHERE (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		AND (? = 0 OR a.semester_id = ?)
+		`+unitFilter+`
+		GROUP BY at.student_id, a.course_id
+		HAVING 100.0 * SUM(at.status = 'absent') / COUNT(*) > ?
+		ORDER BY a.course_id, at.student_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	alerts := make([]scheme.AttendanceAlert, 0)
+
+	for rows.Next() {
+		var alert scheme.AttendanceAlert
+		if err := rows.Scan(&alert.StudentID, &alert.CourseID, &alert.Absent, &alert.Total); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		alert.AbsenceRate = absenceRate(alert.Absent, alert.Total)
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// Get the share of unexcused absences in percent above which the admin is alerted
+func (s *Storage) AttendanceThreshold(ctx context.Context) (float64, error) {
+	const fn = "storage.sqlite.AttendanceThreshold"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	value, ok, err := s.setting(ctx, settingAttendanceThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	if !ok {
+		return defaultAttendanceThreshold, nil
+	}
+
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return threshold, nil
+}
+
+func (s *Storage) SetAttendanceThreshold(ctx context.Context, threshold float64) error {
+	const fn = "storage.sqlite.SetAttendanceThreshold"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if err := saveSetting(ctx, s.db, settingAttendanceThreshold, strconv.FormatFloat(threshold, 'f', -1, 64)); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveAbsenceDocument(ctx context.Context, doc *scheme.AbsenceDocument) (int64, error) {
+	const fn = "storage.sqlite.SaveAbsenceDocument"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, `INSERT INTO absence_documents (student_id, date_from, date_to, reason, file_name, content_type, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		doc.StudentID, doc.DateFrom, doc.DateTo, doc.Reason, doc.FileName, doc.ContentType, doc.Content)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get the documents without their content, studentID and status are not applied if empty
+func (s *Storage) AbsenceDocuments(ctx context.Context, studentID int64, status string) (scheme.AbsenceDocuments, error) {
+	const fn = "storage.sqlite.AbsenceDocuments"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, student_id, date_from, date_to, reason, file_name, content_type, status, reviewer_id, created_at
+		FROM absence_documents
+		WHERE (? = 0 OR student_id = ?) AND (? = '' OR status = ?)
+		ORDER BY created_at, id`, studentID, studentID, status, status)
+	if err != nil {
+		return scheme.AbsenceDocuments{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	docs := scheme.AbsenceDocuments{Documents: make([]scheme.AbsenceDocument, 0)}
+
+	for rows.Next() {
+		doc, err := scanAbsenceDocument(rows)
+		if err != nil {
+			return scheme.AbsenceDocuments{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		docs.Documents = append(docs.Documents, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// Get the document with its content
+func (s *Storage) AbsenceDocument(ctx context.Context, documentID int64) (scheme.AbsenceDocument, error) {
+	const fn = "storage.sqlite.AbsenceDocument"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	row := s.db.QueryRowContext(ctx, `SELECT id, student_id, date_from, date_to, reason, file_name, content_type, status, reviewer_id, created_at
+		FROM absence_documents WHERE id = ?`, documentID)
+
+	doc, err := scanAbsenceDocument(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.AbsenceDocument{}, store.ErrDocumentNotFound
+		}
+		return scheme.AbsenceDocument{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	err = s.db.QueryRowContext(ctx, "SELECT content FROM absence_documents WHERE id = ?", documentID).Scan(&doc.Content)
+	if err != nil {
+		return scheme.AbsenceDocument{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return doc, nil
+}
+
+// Approve or reject the document. Approval turns the student's absences
+// within the document dates into excused ones.
+func (s *Storage) ReviewAbsenceDocument(ctx context.Context, documentID, reviewerID int64, approved bool) error {
+	const fn = "storage.sqlite.ReviewAbsenceDocument"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	status := scheme.DocumentRejected
+	if approved {
+		status = scheme.DocumentApproved
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE absence_documents SET status = ?, reviewer_id = ? WHERE id = ?", status, reviewerID, documentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return store.ErrDocumentNotFound
+	}
+
+	if approved {
+		_, err = tx.ExecContext(ctx, `UPDATE attendance SET status = 'excused'
+			WHERE status = 'absent'
+			AND student_id = (SELECT student_id FROM absence_documents WHERE id = ?)
+			AND session_id IN (
+				SELECT cs.id FROM class_sessions cs, absence_documents d
+				WHERE d.id = ? AND date(cs.session_date) BETWEEN date(d.date_from) AND date(d.date_to)
+			)`, documentID, documentID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAbsenceDocument(row rowScanner) (scheme.AbsenceDocument, error) {
+	var doc scheme.AbsenceDocument
+	var reason sql.NullString
+	var reviewerID sql.NullInt64
+
+	err := row.Scan(&doc.ID, &doc.StudentID, &doc.DateFrom, &doc.DateTo, &reason,
+		&doc.FileName, &doc.ContentType, &doc.Status, &reviewerID, &doc.CreatedAt)
+	if err != nil {
+		return scheme.AbsenceDocument{}, err
+	}
+
+	doc.Reason = reason.String
+	doc.ReviewerID = reviewerID.Int64
+
+	return doc, nil
+}
+
+func absenceRate(absent, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(absent) * 100 / float64(total)
+}
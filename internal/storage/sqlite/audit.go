@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/hashchain"
+)
+
+const auditColumns = "id, at, actor_id, action, entity, entity_id, before, after, request_id, prev_hash, hash"
+
+// appendAudit chains the entry to the latest one within the transaction of
+// the change it records, so the write fails if the audit log cannot be
+// appended. It must follow the writes of tx: SQLite then holds the write lock
+// and no other entry can be chained to the same one. A nil entry is skipped.
+func appendAudit(ctx context.Context, tx *sql.Tx, e *scheme.AuditEntry) error {
+	if e == nil {
+		return nil
+	}
+
+	prev := hashchain.Genesis
+
+	err := tx.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&prev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	e.At = e.At.UTC()
+	e.PrevHash = prev
+	e.Hash = hashchain.Hash(prev, *e)
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO audit_log (at, actor_id, action, entity, entity_id, before, after, request_id, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.At, e.ActorID, e.Action, e.Entity, nullID(e.EntityID), nullJSON(e.Before), nullJSON(e.After), nullString(e.RequestID), e.PrevHash, e.Hash)
+	if err != nil {
+		return err
+	}
+
+	e.ID, err = res.LastInsertId()
+	return err
+}
+
+// Search the audit log, newest first
+func (s *Storage) AuditEntries(ctx context.Context, f scheme.AuditFilter) (scheme.AuditEntries, error) {
+	const fn = "storage.sqlite.AuditEntries"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var where []string
+	var args []any
+
+	if f.ActorID != 0 {
+		where = append(where, "actor_id = ?")
+		args = append(args, f.ActorID)
+	}
+	if f.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.Entity != "" {
+		where = append(where, "entity = ?")
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != 0 {
+		where = append(where, "entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "at >= ?")
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		where = append(where, "at < ?")
+		args = append(args, f.To.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
+
+	entries, err := s.auditEntries(ctx, query, args...)
+	if err != nil {
+		return scheme.AuditEntries{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return scheme.AuditEntries{Entries: entries}, nil
+}
+
+// Get up to limit entries following afterID in chain order
+func (s *Storage) AuditChain(ctx context.Context, afterID int64, limit int) ([]scheme.AuditEntry, error) {
+	const fn = "storage.sqlite.AuditChain"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	entries, err := s.auditEntries(ctx, "SELECT "+auditColumns+" FROM audit_log WHERE id > ? ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return entries, nil
+}
+
+func (s *Storage) auditEntries(ctx context.Context, query string, args ...any) ([]scheme.AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]scheme.AuditEntry, 0)
+
+	for rows.Next() {
+		var e scheme.AuditEntry
+		var entityID sql.NullInt64
+		var before, after, requestID sql.NullString
+
+		err := rows.Scan(&e.ID, &e.At, &e.ActorID, &e.Action, &e.Entity, &entityID, &before, &after, &requestID, &e.PrevHash, &e.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		e.EntityID = entityID.Int64
+		e.RequestID = requestID.String
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
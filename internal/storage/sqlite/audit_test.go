@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/services/audit"
+)
+
+func TestAuditAppendedWithTheWrite(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for i, name := range []string{"CS-21", "CS-22"} {
+		entry := &scheme.AuditEntry{At: time.Now(), ActorID: testAdminID, Action: scheme.AuditCourseCreate, Entity: "course"}
+
+		id, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: name, Number: i + 1}, entry)
+		if err != nil {
+			t.Fatalf("SaveCourse: %v", err)
+		}
+		if entry.EntityID != id {
+			t.Fatalf("audit entity = %d, want course %d", entry.EntityID, id)
+		}
+	}
+
+	res, err := audit.Verify(ctx, s)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !res.Valid || res.Checked != 2 {
+		t.Fatalf("Verify = %+v, want 2 valid entries", res)
+	}
+}
+
+func TestWriteFailsWithoutAudit(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	mustExec(t, s, "DROP TABLE audit_log")
+
+	entry := &scheme.AuditEntry{At: time.Now(), ActorID: testAdminID, Action: scheme.AuditCourseCreate, Entity: "course"}
+	if _, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "CS-21", Number: 1}, entry); err == nil {
+		t.Fatal("SaveCourse succeeded without the audit log")
+	}
+
+	var courses int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM courses WHERE name = 'CS-21'").Scan(&courses); err != nil {
+		t.Fatalf("count courses: %v", err)
+	}
+	if courses != 0 {
+		t.Fatal("course kept although its audit entry failed")
+	}
+}
@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/models"
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+// Save the hash of a new calendar token, the previous tokens of the user are revoked
+func (s *Storage) SaveCalendarToken(ctx context.Context, userID int64, tokenHash string) error {
+	const fn = "storage.sqlite.SaveCalendarToken"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	_, err = tx.ExecContext(ctx, "UPDATE calendar_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", now, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO calendar_tokens (user_id, token_hash, created_at) VALUES (?, ?, ?)", userID, tokenHash, now)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeCalendarTokens(ctx context.Context, userID int64) error {
+	const fn = "storage.sqlite.RevokeCalendarTokens"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE calendar_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return store.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Get the owner of an active calendar token
+func (s *Storage) CalendarTokenUser(ctx context.Context, tokenHash string) (models.Key, error) {
+	const fn = "storage.sqlite.CalendarTokenUser"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var userID int64
+
+	err := s.db.QueryRowContext(ctx, "SELECT user_id FROM calendar_tokens WHERE token_hash = ? AND revoked_at IS NULL", tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Key{}, store.ErrTokenNotFound
+		}
+		return models.Key{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	roles, err := s.RoleList(ctx, userID)
+	if err != nil {
+		return models.Key{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	key := models.Key{ID: userID, Roles: make([]models.Role, 0, len(roles))}
+	for _, role := range roles {
+		key.Roles = append(key.Roles, models.Role{Name: role.Role, Scope: role.UnitID})
+	}
+
+	return key, nil
+}
+
+// Exams the student signed up for, archived years are left out
+const studentExamsQuery = `SELECT e.assignment_id, c.name, d.name, e.exam_date, 0
+	FROM exams e
+	JOIN assignments a ON a.id = e.assignment_id
+	JOIN courses c ON c.id = a.course_id
+	JOIN disciplines d ON d.id = a.discipline_id
+	LEFT JOIN academic_years y ON y.id = c.academic_year_id
+	WHERE e.student_id = ? AND e.exam_date IS NOT NULL AND COALESCE(y.archived, 0) = 0
+	ORDER BY e.exam_date`
+
+// Get the exams the student signed up for, archived years are left out
+func (s *Storage) StudentExamEvents(ctx context.Context, studentID int64) ([]scheme.ExamEvent, error) {
+	const fn = "storage.sqlite.StudentExamEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	events, err := examEvents(ctx, s.db, studentExamsQuery, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return events, nil
+}
+
+// Get the exam dates of the teacher's assignments with the number of registered students
+func (s *Storage) TeacherExamEvents(ctx context.Context, teacherID int64) ([]scheme.ExamEvent, error) {
+	const fn = "storage.sqlite.TeacherExamEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	events, err := examEvents(ctx, s.db, `SELECT e.assignment_id, c.name, d.name, e.exam_date, COUNT(*)
+		FROM exams e
+		JOIN assignments a ON a.id = e.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		JOIN disciplines d ON d.id = a.discipline_id
+		LEFT JOIN academic_years y ON y.id = c.academic_year_id
+		WHERE a.teacher_id = ? AND e.exam_date IS NOT NULL AND COALESCE(y.archived, 0) = 0
+		GROUP BY e.assignment_id, e.exam_date
+		ORDER BY e.exam_date`, teacherID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return events, nil
+}
+
+func examEvents(ctx context.Context, db querier, query string, id int64) ([]scheme.ExamEvent, error) {
+	rows, err := db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]scheme.ExamEvent, 0)
+
+	for rows.Next() {
+		var e scheme.ExamEvent
+		if err := rows.Scan(&e.AssignmentID, &e.CourseName, &e.DisciplineName, &e.ExamDate, &e.Students); err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// Get the student's lessons, archived academic years are left out
+func (s *Storage) StudentLessonEvents(ctx context.Context, studentID int64) ([]scheme.LessonEvent, error) {
+	const fn = "storage.sqlite.StudentLessonEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	events, err := s.lessonEvents(ctx, "a.course_id IN (SELECT course_id FROM enrollments WHERE student_id = ?)", studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return events, nil
+}
+
+// Get the teacher's lessons, archived academic years are left out
+func (s *Storage) TeacherLessonEvents(ctx context.Context, teacherID int64) ([]scheme.LessonEvent, error) {
+	const fn = "storage.sqlite.TeacherLessonEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	events, err := s.lessonEvents(ctx, "a.teacher_id = ?", teacherID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return events, nil
+}
+
+// A lesson repeats within the semester of its assignment or, without one, within the academic year
+func (s *Storage) lessonEvents(ctx context.Context, cond string, id int64) ([]scheme.LessonEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+lessonColumns+`, c.name, d.name, r.name,
+			y.start_date, y.end_date, sem.start_date, sem.end_date
+		FROM lessons l
+		JOIN assignments a ON a.id = l.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		JOIN disciplines d ON d.id = a.discipline_id
+		JOIN rooms r ON r.id = l.room_id
+		LEFT JOIN academic_years y ON y.id = c.academic_year_id
+		LEFT JOIN semesters sem ON sem.id = a.semester_id
+		WHERE `+cond+` AND COALESCE(y.archived, 0) = 0
+		ORDER BY l.weekday, l.start_time`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]scheme.LessonEvent, 0)
+
+	for rows.Next() {
+		var e scheme.LessonEvent
+		var yearStart, yearEnd, semStart, semEnd sql.NullTime
+		l := &e.Lesson
+
+		err := rows.Scan(&l.ID, &l.AssignmentID, &l.CourseID, &l.DisciplineID, &l.TeacherID, &l.RoomID,
+			&l.Weekday, &l.StartTime, &l.EndTime, &l.WeekParity,
+			&e.CourseName, &e.DisciplineName, &e.RoomName, &yearStart, &yearEnd, &semStart, &semEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case semStart.Valid && semEnd.Valid:
+			e.TermStart, e.TermEnd = semStart.Time, semEnd.Time
+		case yearStart.Valid && yearEnd.Valid:
+			e.TermStart, e.TermEnd = yearStart.Time, yearEnd.Time
+		default:
+			// Neither the semester nor the year is known, the lesson cannot be put on dates
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
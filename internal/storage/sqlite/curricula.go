@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func (s *Storage) SaveProgramme(ctx context.Context, programme *scheme.Programme) (int64, error) {
+	const fn = "storage.sqlite.SaveProgramme"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO programmes (code, name, unit_id) VALUES (?, ?, ?)",
+		programme.Code, programme.Name, nullID(programme.UnitID))
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) Programmes(ctx context.Context) (scheme.Programmes, error) {
+	const fn = "storage.sqlite.Programmes"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, code, name, unit_id FROM programmes ORDER BY code")
+	if err != nil {
+		return scheme.Programmes{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	programmes := scheme.Programmes{Programmes: make([]scheme.Programme, 0)}
+
+	for rows.Next() {
+		var p scheme.Programme
+		var unitID sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Code, &p.Name, &unitID); err != nil {
+			return scheme.Programmes{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		p.UnitID = unitID.Int64
+
+		programmes.Programmes = append(programmes.Programmes, p)
+	}
+
+	return programmes, rows.Err()
+}
+
+// Get the Programme by ID
+func (s *Storage) Programme(ctx context.Context, programmeID int64) (scheme.Programme, error) {
+	const fn = "storage.sqlite.Programme"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var p scheme.Programme
+	var unitID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, code, name, unit_id FROM programmes WHERE id = ?", programmeID).
+		Scan(&p.ID, &p.Code, &p.Name, &unitID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Programme{}, store.ErrProgrammeNotFound
+		}
+		return scheme.Programme{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	p.UnitID = unitID.Int64
+
+	return p, nil
+}
+
+// Save the curriculum together with its items
+func (s *Storage) SaveCurriculum(ctx context.Context, c *scheme.Curriculum) (int64, error) {
+	const fn = "storage.sqlite.SaveCurriculum"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO curricula (programme_id, name, admission_year, elective_credits) VALUES (?, ?, ?, ?)",
+		c.ProgrammeID, c.Name, c.AdmissionYear, c.ElectiveCredits)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	curriculumID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO curriculum_items
+		(curriculum_id, discipline_id, semester, kind, credit_hours, assessment) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	for _, item := range c.Items {
+		_, err = stmt.ExecContext(ctx, curriculumID, item.DisciplineID, item.Semester, item.Kind, item.CreditHours, item.Assessment)
+		if err != nil {
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return curriculumID, nil
+}
+
+// Get the Curriculum with its items by ID
+func (s *Storage) Curriculum(ctx context.Context, curriculumID int64) (scheme.Curriculum, error) {
+	const fn = "storage.sqlite.Curriculum"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var c scheme.Curriculum
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, programme_id, name, admission_year, elective_credits FROM curricula WHERE id = ?", curriculumID).
+		Scan(&c.ID, &c.ProgrammeID, &c.Name, &c.AdmissionYear, &c.ElectiveCredits)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Curriculum{}, store.ErrCurriculumNotFound
+		}
+		return scheme.Curriculum{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT discipline_id, semester, kind, credit_hours, assessment
+		FROM curriculum_items WHERE curriculum_id = ? ORDER BY semester, id`, curriculumID)
+	if err != nil {
+		return scheme.Curriculum{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	c.Items = make([]scheme.CurriculumItem, 0)
+
+	for rows.Next() {
+		var item scheme.CurriculumItem
+		if err := rows.Scan(&item.DisciplineID, &item.Semester, &item.Kind, &item.CreditHours, &item.Assessment); err != nil {
+			return scheme.Curriculum{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		c.Items = append(c.Items, item)
+	}
+
+	return c, rows.Err()
+}
+
+// Put the students on the curriculum
+func (s *Storage) SaveCurriculumStudents(ctx context.Context, curriculumID int64, studentIDs []int64) error {
+	const fn = "storage.sqlite.SaveCurriculumStudents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range studentIDs {
+		res, err := tx.ExecContext(ctx, "UPDATE students SET curriculum_id = ? WHERE user_id = ?", curriculumID, id)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO students (user_id, curriculum_id) VALUES (?, ?)", id, curriculumID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) StudentCurriculumID(ctx context.Context, studentID int64) (int64, error) {
+	const fn = "storage.sqlite.StudentCurriculumID"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var id sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, "SELECT curriculum_id FROM students WHERE user_id = ? AND curriculum_id IS NOT NULL", studentID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, store.ErrCurriculumNotFound
+		}
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id.Int64, nil
+}
+
+// StudentGrades returns the latest grade of the student in every discipline
+func (s *Storage) StudentGrades(ctx context.Context, studentID int64) (map[int64]scheme.Grade, error) {
+	const fn = "storage.sqlite.StudentGrades"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT a.discipline_id, g.id, g.exam_id, g.teacher_id, g.grade, g.scale_id, g.grade >= sc.pass_value, g.grade_date
+		FROM grades g
+		JOIN grading_scales sc ON sc.id = g.scale_id
+		JOIN exams e ON e.id = g.exam_id
+		JOIN assignments a ON a.id = e.assignment_id
+		WHERE e.student_id = ?
+		ORDER BY g.grade_date, g.id`, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	grades := make(map[int64]scheme.Grade)
+
+	for rows.Next() {
+		var disciplineID int64
+		var grade scheme.Grade
+		if err := rows.Scan(&disciplineID, &grade.ID, &grade.ExamID, &grade.TeacherID, &grade.Grade, &grade.ScaleID, &grade.Passed, &grade.GradeDate); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		grades[disciplineID] = grade
+	}
+
+	return grades, rows.Err()
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func (s *Storage) SaveAcademicEvent(ctx context.Context, event *scheme.AcademicEvent) (int64, error) {
+	const fn = "storage.sqlite.SaveAcademicEvent"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.yearArchived(ctx, event.AcademicYearID)
+	if err != nil {
+		return 0, err
+	}
+	if archived {
+		return 0, store.ErrPeriodArchived
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO academic_events (academic_year_id, kind, name, date_from, date_to) VALUES (?, ?, ?, ?, ?)",
+		nullID(event.AcademicYearID), event.Kind, event.Name, event.DateFrom, event.DateTo)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get the events of the academic year together with the events of every year,
+// all events if yearID is zero. kind is not applied if empty.
+func (s *Storage) AcademicEvents(ctx context.Context, yearID int64, kind string) (scheme.AcademicEvents, error) {
+	const fn = "storage.sqlite.AcademicEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, academic_year_id, kind, name, date_from, date_to
+		FROM academic_events
+		WHERE (? = 0 OR academic_year_id = ? OR academic_year_id IS NULL)
+		AND (? = '' OR kind = ?)
+		ORDER BY date_from, id`, yearID, yearID, kind, kind)
+	if err != nil {
+		return scheme.AcademicEvents{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	events := scheme.AcademicEvents{Events: make([]scheme.AcademicEvent, 0)}
+
+	for rows.Next() {
+		var e scheme.AcademicEvent
+		var yID sql.NullInt64
+		if err := rows.Scan(&e.ID, &yID, &e.Kind, &e.Name, &e.DateFrom, &e.DateTo); err != nil {
+			return scheme.AcademicEvents{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		e.AcademicYearID = yID.Int64
+
+		events.Events = append(events.Events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *Storage) DeleteAcademicEvent(ctx context.Context, eventID int64) error {
+	const fn = "storage.sqlite.DeleteAcademicEvent"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var yearID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, "SELECT academic_year_id FROM academic_events WHERE id = ?", eventID).Scan(&yearID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrEventNotFound
+		}
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	archived, err := s.yearArchived(ctx, yearID.Int64)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM academic_events WHERE id = ?", eventID); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the academic year of the assignment's course or semester, zero if the
+// assignment is not bound to a year
+func (s *Storage) AssignmentYearID(ctx context.Context, assignmentID int64) (int64, error) {
+	const fn = "storage.sqlite.AssignmentYearID"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var yearID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(c.academic_year_id, sm.academic_year_id) FROM assignments a
+		LEFT JOIN courses c ON c.id = a.course_id
+		LEFT JOIN semesters sm ON sm.id = a.semester_id
+		WHERE a.id = ?`, assignmentID).Scan(&yearID)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return yearID.Int64, nil
+}
@@ -0,0 +1,230 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func (s *Storage) SaveComponent(ctx context.Context, c *scheme.Component) (int64, error) {
+	const fn = "storage.sqlite.SaveComponent"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, c.AssignmentID)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return 0, store.ErrPeriodArchived
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO grade_components (assignment_id, name, weight, max_score, due_date) VALUES (?, ?, ?, ?, ?)",
+		c.AssignmentID, c.Name, c.Weight, c.MaxScore, c.DueDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get the components of the assignment, with the student's scores if studentID is not zero
+func (s *Storage) Components(ctx context.Context, assignmentID, studentID int64) ([]scheme.Component, error) {
+	const fn = "storage.sqlite.Components"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT c.id, c.name, c.weight, c.max_score, c.due_date, cs.score
+		FROM grade_components c
+		LEFT JOIN component_scores cs ON cs.component_id = c.id AND cs.student_id = ?
+		WHERE c.assignment_id = ?
+		ORDER BY c.due_date, c.id`, studentID, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	components := make([]scheme.Component, 0)
+
+	for rows.Next() {
+		var c scheme.Component
+		var dueDate sql.NullTime
+		var score sql.NullFloat64
+		if err := rows.Scan(&c.ID, &c.Name, &c.Weight, &c.MaxScore, &dueDate, &score); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		c.AssignmentID = assignmentID
+		if dueDate.Valid {
+			c.DueDate = &dueDate.Time
+		}
+		if score.Valid {
+			c.Score = &score.Float64
+		}
+
+		components = append(components, c)
+	}
+
+	return components, rows.Err()
+}
+
+// Save the scores of the assignment components, existing scores are overwritten
+func (s *Storage) SaveScores(ctx context.Context, assignmentID, teacherID int64, scores []scheme.Score) error {
+	const fn = "storage.sqlite.SaveScores"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	for _, score := range scores {
+		var maxScore float64
+
+		err := tx.QueryRowContext(ctx, "SELECT max_score FROM grade_components WHERE id = ? AND assignment_id = ?",
+			score.ComponentID, assignmentID).Scan(&maxScore)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrComponentNotFound
+			}
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+
+		if score.Score < 0 || score.Score > maxScore {
+			return store.ErrInvalidScore
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO component_scores (component_id, student_id, teacher_id, score, score_date)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(component_id, student_id) DO UPDATE SET
+				teacher_id = excluded.teacher_id, score = excluded.score, score_date = excluded.score_date`,
+			score.ComponentID, score.StudentID, teacherID, score.Score, now)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// AssignmentScores returns the scores of the assignment by student and component
+func (s *Storage) AssignmentScores(ctx context.Context, assignmentID int64) (map[int64]map[int64]float64, error) {
+	const fn = "storage.sqlite.AssignmentScores"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT cs.student_id, cs.component_id, cs.score
+		FROM component_scores cs JOIN grade_components c ON c.id = cs.component_id
+		WHERE c.assignment_id = ?`, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	scores := make(map[int64]map[int64]float64)
+
+	for rows.Next() {
+		var studentID, componentID int64
+		var score float64
+		if err := rows.Scan(&studentID, &componentID, &score); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		if scores[studentID] == nil {
+			scores[studentID] = make(map[int64]float64)
+		}
+		scores[studentID][componentID] = score
+	}
+
+	return scores, rows.Err()
+}
+
+// AssignmentStudents returns the students enrolled in the course of the assignment
+func (s *Storage) AssignmentStudents(ctx context.Context, assignmentID int64) ([]int64, error) {
+	const fn = "storage.sqlite.AssignmentStudents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT e.student_id FROM enrollments e
+		JOIN assignments a ON a.course_id = e.course_id
+		WHERE a.id = ? ORDER BY e.student_id`, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	students := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		students = append(students, id)
+	}
+
+	return students, rows.Err()
+}
+
+func (s *Storage) AssignmentFormula(ctx context.Context, assignmentID int64) (string, error) {
+	const fn = "storage.sqlite.AssignmentFormula"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var formula string
+
+	err := s.db.QueryRowContext(ctx, "SELECT final_formula FROM assignments WHERE id = ?", assignmentID).Scan(&formula)
+	if err != nil {
+		return "", fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return formula, nil
+}
+
+func (s *Storage) SetAssignmentFormula(ctx context.Context, assignmentID int64, formula string) error {
+	const fn = "storage.sqlite.SetAssignmentFormula"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	_, err = s.db.ExecContext(ctx, "UPDATE assignments SET final_formula = ? WHERE id = ?", formula, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func (s *Storage) Scales(ctx context.Context) (scheme.Scales, error) {
+	const fn = "storage.sqlite.Scales"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, code, name, min_value, max_value, pass_value FROM grading_scales ORDER BY id")
+	if err != nil {
+		return scheme.Scales{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	scales := scheme.Scales{Scales: make([]scheme.Scale, 0)}
+
+	for rows.Next() {
+		var scale scheme.Scale
+		if err := rows.Scan(&scale.ID, &scale.Code, &scale.Name, &scale.Min, &scale.Max, &scale.Pass); err != nil {
+			return scheme.Scales{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		scales.Scales = append(scales.Scales, scale)
+	}
+	if err := rows.Err(); err != nil {
+		return scheme.Scales{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	scales.Conversions, err = s.ScaleConversions(ctx)
+	if err != nil {
+		return scheme.Scales{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return scales, nil
+}
+
+func (s *Storage) scale(ctx context.Context, query string, arg any) (scheme.Scale, error) {
+	var scale scheme.Scale
+
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(&scale.ID, &scale.Code, &scale.Name, &scale.Min, &scale.Max, &scale.Pass)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Scale{}, store.ErrScaleNotFound
+		}
+		return scheme.Scale{}, err
+	}
+
+	return scale, nil
+}
+
+// Get the Scale by code
+func (s *Storage) ScaleByCode(ctx context.Context, code string) (scheme.Scale, error) {
+	const fn = "storage.sqlite.ScaleByCode"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	scale, err := s.scale(ctx, "SELECT id, code, name, min_value, max_value, pass_value FROM grading_scales WHERE code = ?", code)
+	if err != nil && !errors.Is(err, store.ErrScaleNotFound) {
+		return scheme.Scale{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return scale, err
+}
+
+// Get the Scale the assignment is graded on
+func (s *Storage) AssignmentScale(ctx context.Context, assignmentID int64) (scheme.Scale, error) {
+	const fn = "storage.sqlite.AssignmentScale"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	scale, err := s.scale(ctx, `SELECT sc.id, sc.code, sc.name, sc.min_value, sc.max_value, sc.pass_value
+		FROM grading_scales sc JOIN assignments a ON a.scale_id = sc.id WHERE a.id = ?`, assignmentID)
+	if err != nil && !errors.Is(err, store.ErrScaleNotFound) {
+		return scheme.Scale{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return scale, err
+}
+
+func (s *Storage) SaveScale(ctx context.Context, scale *scheme.Scale) (int64, error) {
+	const fn = "storage.sqlite.SaveScale"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO grading_scales (code, name, min_value, max_value, pass_value) VALUES (?, ?, ?, ?, ?)",
+		scale.Code, scale.Name, scale.Min, scale.Max, scale.Pass)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) ScaleConversions(ctx context.Context) ([]scheme.Conversion, error) {
+	const fn = "storage.sqlite.ScaleConversions"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT from_scale_id, to_scale_id, from_min, from_max, to_value
+		FROM scale_conversions ORDER BY from_scale_id, to_scale_id, from_min`)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	rules := make([]scheme.Conversion, 0)
+
+	for rows.Next() {
+		var rule scheme.Conversion
+		if err := rows.Scan(&rule.FromScaleID, &rule.ToScaleID, &rule.FromMin, &rule.FromMax, &rule.ToValue); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// SaveConversions replaces the rules of every scale pair present in rules
+func (s *Storage) SaveConversions(ctx context.Context, rules []scheme.Conversion) error {
+	const fn = "storage.sqlite.SaveConversions"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	cleared := make(map[[2]int64]bool)
+
+	for _, rule := range rules {
+		pair := [2]int64{rule.FromScaleID, rule.ToScaleID}
+		if !cleared[pair] {
+			_, err := tx.ExecContext(ctx, "DELETE FROM scale_conversions WHERE from_scale_id = ? AND to_scale_id = ?", pair[0], pair[1])
+			if err != nil {
+				return fmt.Errorf("%s:%w", fn, err)
+			}
+			cleared[pair] = true
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO scale_conversions (from_scale_id, to_scale_id, from_min, from_max, to_value)
+			VALUES (?, ?, ?, ?, ?)`, rule.FromScaleID, rule.ToScaleID, rule.FromMin, rule.FromMax, rule.ToValue)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SetAssignmentScale(ctx context.Context, assignmentID, scaleID int64, audit *scheme.AuditEntry) error {
+	const fn = "storage.sqlite.SetAssignmentScale"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE assignments SET scale_id = ? WHERE id = ?", scaleID, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/services/gradebook"
+)
+
+// Every total of the gradebook must suggest a grade on the scales assignments use
+func TestRatingConvertsToAssignmentScales(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	rating, err := s.ScaleByCode(ctx, scheme.ScaleRating100)
+	if err != nil {
+		t.Fatalf("ScaleByCode: %v", err)
+	}
+
+	rules, err := s.ScaleConversions(ctx)
+	if err != nil {
+		t.Fatalf("ScaleConversions: %v", err)
+	}
+
+	for _, code := range []string{scheme.ScaleFivePoint, "pass_fail", "differentiated", "coursework"} {
+		scale, err := s.ScaleByCode(ctx, code)
+		if err != nil {
+			t.Fatalf("ScaleByCode(%s): %v", code, err)
+		}
+
+		for total := rating.Min; total <= rating.Max; total++ {
+			grade, err := gradebook.Suggest(float64(total), rating, scale, rules)
+			if err != nil {
+				t.Fatalf("%s: no grade for total %d: %v", code, total, err)
+			}
+			if grade < scale.Min || grade > scale.Max {
+				t.Fatalf("%s: grade %d for total %d is out of scale", code, grade, total)
+			}
+		}
+	}
+}
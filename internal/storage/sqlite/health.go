@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the migration the code is written against, bump it
+// together with every new migration
+const SchemaVersion = 20
+
+// Ping checks that the database can be reached
+func (s *Storage) Ping(ctx context.Context) error {
+	const fn = "storage.sqlite.Ping"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if err := s.db.Ping(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// MigrationVersion is the last migration applied by cmd/migrator; dirty is
+// true if it failed halfway. A database without migrations is at version 0.
+func (s *Storage) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	const fn = "storage.sqlite.MigrationVersion"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var exists int
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'migrations'").Scan(&exists)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s:%w", fn, err)
+	}
+	if exists == 0 {
+		return 0, false, nil
+	}
+
+	err = s.db.QueryRowContext(ctx, "SELECT version, dirty FROM migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func (s *Storage) UserEmail(ctx context.Context, userID int64) (string, error) {
+	const fn = "storage.sqlite.UserEmail"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var email string
+
+	err := s.db.QueryRowContext(ctx, "SELECT email FROM users WHERE id = ?", userID).Scan(&email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", store.ErrUserNotFound
+		}
+		return "", fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return email, nil
+}
+
+// Get the notification preferences of the user, Russian with every
+// notification enabled if the user never changed them
+func (s *Storage) NotificationPreferences(ctx context.Context, userID int64) (scheme.NotificationPreferences, error) {
+	const fn = "storage.sqlite.NotificationPreferences"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	prefs := scheme.NotificationPreferences{Language: scheme.LangRU, Disabled: make([]string, 0)}
+
+	err := s.db.QueryRowContext(ctx, "SELECT language FROM notification_preferences WHERE user_id = ?", userID).Scan(&prefs.Language)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return scheme.NotificationPreferences{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT kind FROM notification_optouts WHERE user_id = ? ORDER BY kind", userID)
+	if err != nil {
+		return scheme.NotificationPreferences{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return scheme.NotificationPreferences{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		prefs.Disabled = append(prefs.Disabled, kind)
+	}
+
+	return prefs, rows.Err()
+}
+
+// Replace the notification preferences of the user
+func (s *Storage) SetNotificationPreferences(ctx context.Context, userID int64, prefs scheme.NotificationPreferences) error {
+	const fn = "storage.sqlite.SetNotificationPreferences"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO notification_preferences (user_id, language) VALUES (?, ?) ON CONFLICT(user_id) DO UPDATE SET language = excluded.language",
+		userID, prefs.Language)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM notification_optouts WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO notification_optouts (user_id, kind) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	for _, kind := range prefs.Disabled {
+		if _, err := stmt.ExecContext(ctx, userID, kind); err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Put the email into the outbox
+func (s *Storage) SaveNotification(ctx context.Context, n *scheme.Notification) (int64, error) {
+	const fn = "storage.sqlite.SaveNotification"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO notification_outbox (user_id, kind, email, subject, body, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?)",
+		n.UserID, n.Kind, n.Email, n.Subject, n.Body, n.NextAttemptAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get up to limit emails of the outbox which are due to be sent at now
+func (s *Storage) DueNotifications(ctx context.Context, now time.Time, limit int) ([]scheme.Notification, error) {
+	const fn = "storage.sqlite.DueNotifications"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, kind, email, subject, body, attempts, next_attempt_at
+		FROM notification_outbox
+		WHERE sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id
+		LIMIT ?`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	notifications := make([]scheme.Notification, 0)
+
+	for rows.Next() {
+		var n scheme.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Email, &n.Subject, &n.Body, &n.Attempts, &n.NextAttemptAt); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *Storage) NotificationSent(ctx context.Context, id int64, at time.Time) error {
+	const fn = "storage.sqlite.NotificationSent"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE notification_outbox SET attempts = attempts + 1, sent_at = ?, last_error = NULL WHERE id = ?", at, id)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Record a failed attempt. The email is retried at next, a zero next gives it up.
+func (s *Storage) NotificationFailed(ctx context.Context, id int64, sendErr string, next time.Time) error {
+	const fn = "storage.sqlite.NotificationFailed"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var err error
+	if next.IsZero() {
+		_, err = s.db.ExecContext(ctx, "UPDATE notification_outbox SET attempts = attempts + 1, last_error = ?, failed_at = ? WHERE id = ?",
+			sendErr, time.Now(), id)
+	} else {
+		_, err = s.db.ExecContext(ctx, "UPDATE notification_outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?",
+			sendErr, next, id)
+	}
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,375 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+const settingCurrentSemester = "current_semester_id"
+
+// Save the academic year together with its semesters
+func (s *Storage) SaveAcademicYear(ctx context.Context, year *scheme.AcademicYear) (int64, error) {
+	const fn = "storage.sqlite.SaveAcademicYear"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO academic_years (name, start_date, end_date) VALUES (?, ?, ?)",
+		year.Name, year.StartDate, year.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	yearID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO semesters (academic_year_id, num, start_date, end_date) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	for _, sem := range year.Semesters {
+		_, err = stmt.ExecContext(ctx, yearID, sem.Number, sem.StartDate, sem.EndDate)
+		if err != nil {
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return yearID, nil
+}
+
+// Get all academic years with their semesters and the current semester
+func (s *Storage) AcademicYears(ctx context.Context) (scheme.AcademicYears, error) {
+	const fn = "storage.sqlite.AcademicYears"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, start_date, end_date, archived FROM academic_years ORDER BY start_date")
+	if err != nil {
+		return scheme.AcademicYears{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	var years scheme.AcademicYears
+
+	for rows.Next() {
+		var year scheme.AcademicYear
+		if err := rows.Scan(&year.ID, &year.Name, &year.StartDate, &year.EndDate, &year.Archived); err != nil {
+			return scheme.AcademicYears{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		years.AcademicYears = append(years.AcademicYears, year)
+	}
+	if err := rows.Err(); err != nil {
+		return scheme.AcademicYears{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	for i, year := range years.AcademicYears {
+		semesters, err := s.Semesters(ctx, year.ID)
+		if err != nil {
+			return scheme.AcademicYears{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		years.AcademicYears[i].Semesters = semesters
+	}
+
+	current, err := s.CurrentPeriod(ctx)
+	if err != nil && !errors.Is(err, store.ErrPeriodNotFound) {
+		return scheme.AcademicYears{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	years.CurrentSemesterID = current.SemesterID
+
+	return years, nil
+}
+
+func (s *Storage) Semesters(ctx context.Context, yearID int64) ([]scheme.Semester, error) {
+	const fn = "storage.sqlite.Semesters"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, num, start_date, end_date FROM semesters WHERE academic_year_id = ? ORDER BY num")
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, yearID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	semesters := make([]scheme.Semester, 0)
+
+	for rows.Next() {
+		var sem scheme.Semester
+		if err := rows.Scan(&sem.ID, &sem.Number, &sem.StartDate, &sem.EndDate); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		sem.AcademicYearID = yearID
+
+		semesters = append(semesters, sem)
+	}
+
+	return semesters, rows.Err()
+}
+
+// Get the Semester by ID
+func (s *Storage) Semester(ctx context.Context, semesterID int64) (scheme.Semester, error) {
+	const fn = "storage.sqlite.Semester"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, academic_year_id, num, start_date, end_date FROM semesters WHERE id = ?")
+	if err != nil {
+		return scheme.Semester{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	var sem scheme.Semester
+
+	err = stmt.QueryRowContext(ctx, semesterID).Scan(&sem.ID, &sem.AcademicYearID, &sem.Number, &sem.StartDate, &sem.EndDate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Semester{}, store.ErrPeriodNotFound
+		}
+		return scheme.Semester{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return sem, nil
+}
+
+func (s *Storage) SetCurrentSemester(ctx context.Context, semesterID int64) error {
+	const fn = "storage.sqlite.SetCurrentSemester"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if _, err := s.Semester(ctx, semesterID); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value",
+		settingCurrentSemester, strconv.FormatInt(semesterID, 10))
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the current semester and the academic year it belongs to
+func (s *Storage) CurrentPeriod(ctx context.Context) (scheme.Period, error) {
+	const fn = "storage.sqlite.CurrentPeriod"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var value string
+
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", settingCurrentSemester).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Period{}, store.ErrPeriodNotFound
+		}
+		return scheme.Period{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	semesterID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return scheme.Period{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	sem, err := s.Semester(ctx, semesterID)
+	if err != nil {
+		return scheme.Period{}, err
+	}
+
+	return scheme.Period{AcademicYearID: sem.AcademicYearID, SemesterID: sem.ID}, nil
+}
+
+// YearStudents returns the students enrolled in the courses of the academic
+// year, courses without a year included. Year 0 returns every enrolled student.
+func (s *Storage) YearStudents(ctx context.Context, yearID int64) ([]int64, error) {
+	const fn = "storage.sqlite.YearStudents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT e.student_id FROM enrollments e
+		JOIN courses c ON c.id = e.course_id
+		WHERE (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		ORDER BY e.student_id`, yearID, yearID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	students := make([]int64, 0)
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		students = append(students, id)
+	}
+
+	return students, rows.Err()
+}
+
+func (s *Storage) ArchiveAcademicYear(ctx context.Context, yearID int64, archived bool) error {
+	const fn = "storage.sqlite.ArchiveAcademicYear"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE academic_years SET archived = ? WHERE id = ?", archived, yearID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return store.ErrPeriodNotFound
+	}
+
+	return nil
+}
+
+// courseInPeriod reports whether the course runs in the given period.
+// A course matches a semester if any of its assignments is scheduled for it.
+// Courses created before academic years existed have none and match every year.
+func (s *Storage) courseInPeriod(ctx context.Context, courseID int64, period scheme.Period) (bool, error) {
+	const fn = "storage.sqlite.courseInPeriod"
+
+	var n int
+
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM courses c
+		WHERE c.id = ?
+		AND (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		AND (? = 0 OR EXISTS (SELECT 1 FROM assignments a WHERE a.course_id = c.id AND a.semester_id = ?))`,
+		courseID,
+		period.AcademicYearID, period.AcademicYearID,
+		period.SemesterID, period.SemesterID,
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return n > 0, nil
+}
+
+// checkSubjectSemesters reports ErrInvalidSemester if a semester of the
+// subjects lies outside the academic year and ErrPeriodArchived if its year
+// is archived
+func checkSubjectSemesters(ctx context.Context, tx *sql.Tx, yearID int64, subjects []scheme.Subject) error {
+	for _, subject := range subjects {
+		if subject.SemesterID == 0 {
+			continue
+		}
+
+		var semesterYear int64
+		var archived bool
+
+		err := tx.QueryRowContext(ctx, `SELECT sm.academic_year_id, y.archived FROM semesters sm
+			JOIN academic_years y ON y.id = sm.academic_year_id
+			WHERE sm.id = ?`, subject.SemesterID).Scan(&semesterYear, &archived)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrPeriodNotFound
+			}
+			return err
+		}
+
+		if semesterYear != yearID {
+			return store.ErrInvalidSemester
+		}
+		if archived {
+			return store.ErrPeriodArchived
+		}
+	}
+	return nil
+}
+
+func (s *Storage) yearArchived(ctx context.Context, yearID int64) (bool, error) {
+	const fn = "storage.sqlite.yearArchived"
+
+	if yearID == 0 {
+		return false, nil
+	}
+
+	var archived bool
+
+	err := s.db.QueryRowContext(ctx, "SELECT archived FROM academic_years WHERE id = ?", yearID).Scan(&archived)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, store.ErrPeriodNotFound
+		}
+		return false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return archived, nil
+}
+
+// courseArchived reports whether the course belongs to an archived academic year
+func (s *Storage) courseArchived(ctx context.Context, courseID int64) (bool, error) {
+	const fn = "storage.sqlite.courseArchived"
+
+	var n int
+
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM courses c
+		JOIN academic_years y ON y.id = c.academic_year_id
+		WHERE c.id = ? AND y.archived = 1`, courseID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return n > 0, nil
+}
+
+// assignmentArchived reports whether the assignment's course or semester
+// belongs to an archived academic year
+func (s *Storage) assignmentArchived(ctx context.Context, assignmentID int64) (bool, error) {
+	const fn = "storage.sqlite.assignmentArchived"
+
+	var n int
+
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM assignments a
+		LEFT JOIN courses c ON c.id = a.course_id
+		LEFT JOIN semesters sm ON sm.id = a.semester_id
+		JOIN academic_years y ON y.id = c.academic_year_id OR y.id = sm.academic_year_id
+		WHERE a.id = ? AND y.archived = 1`, assignmentID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return n > 0, nil
+}
+
+func nullID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+func saveYear(t *testing.T, s *Storage, name string) scheme.AcademicYear {
+	t.Helper()
+	ctx := context.Background()
+
+	start := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	id, err := s.SaveAcademicYear(ctx, &scheme.AcademicYear{
+		Name:      name,
+		StartDate: start,
+		EndDate:   start.AddDate(1, 0, 0),
+		Semesters: []scheme.Semester{
+			{Number: 1, StartDate: start, EndDate: start.AddDate(0, 4, 0)},
+			{Number: 2, StartDate: start.AddDate(0, 5, 0), EndDate: start.AddDate(0, 10, 0)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveAcademicYear: %v", err)
+	}
+
+	semesters, err := s.Semesters(ctx, id)
+	if err != nil {
+		t.Fatalf("Semesters: %v", err)
+	}
+	return scheme.AcademicYear{ID: id, Semesters: semesters}
+}
+
+func TestCoursesWithoutYearMatchEveryYear(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	year := saveYear(t, s, "2025/2026")
+
+	// A course created before academic years existed
+	legacyID := mustExec(t, s, "INSERT INTO courses (num, name) VALUES (1, 'Legacy')")
+	mustExec(t, s, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)", legacyID, testStudentID)
+
+	currentID, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "Current", Number: 2, AcademicYearID: year.ID}, nil)
+	if err != nil {
+		t.Fatalf("SaveCourse: %v", err)
+	}
+	mustExec(t, s, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)", currentID, testStudentID)
+
+	if err := s.SetCurrentSemester(ctx, year.Semesters[0].ID); err != nil {
+		t.Fatalf("SetCurrentSemester: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		period scheme.Period
+		want   []string
+	}{
+		{"current year", scheme.Period{AcademicYearID: year.ID}, []string{"Legacy", "Current"}},
+		{"other year", scheme.Period{AcademicYearID: year.ID + 1}, []string{"Legacy"}},
+		{"all", scheme.Period{}, []string{"Legacy", "Current"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses, err := s.StudentCourses(ctx, testStudentID, tt.period)
+			if err != nil {
+				t.Fatalf("StudentCourses: %v", err)
+			}
+
+			got := make([]string, 0)
+			for _, c := range courses.Courses {
+				got = append(got, c.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("courses = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("courses = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveCourseChecksSemesters(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	year := saveYear(t, s, "2025/2026")
+	other := saveYear(t, s, "2026/2027")
+	archived := saveYear(t, s, "2024/2025")
+	if err := s.ArchiveAcademicYear(ctx, archived.ID, true); err != nil {
+		t.Fatalf("ArchiveAcademicYear: %v", err)
+	}
+
+	mustExec(t, s, "INSERT INTO disciplines (name) VALUES ('Algebra')")
+
+	tests := []struct {
+		name       string
+		yearID     int64
+		semesterID int64
+		want       error
+	}{
+		{"semester of the year", year.ID, year.Semesters[0].ID, nil},
+		{"semester of another year", year.ID, other.Semesters[0].ID, store.ErrInvalidSemester},
+		{"unknown semester", year.ID, 999, store.ErrPeriodNotFound},
+		{"archived semester", archived.ID, archived.Semesters[1].ID, store.ErrPeriodArchived},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SaveCourse(ctx, &scheme.CourseCreation{
+				Name:           tt.name,
+				Number:         i + 1,
+				AcademicYearID: tt.yearID,
+				Subjects:       []scheme.Subject{{TeacherID: testTeacherID, DisciplineID: 1, SemesterID: tt.semesterID}},
+			}, nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("SaveCourse error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCourseNamesUniqueWithoutYear(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "Physics", Number: 1}, nil); err != nil {
+		t.Fatalf("SaveCourse: %v", err)
+	}
+	if _, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "Physics", Number: 2}, nil); err == nil {
+		t.Fatal("duplicate course name without academic year was saved")
+	}
+
+	year := saveYear(t, s, "2025/2026")
+	if _, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "Physics", Number: 3, AcademicYearID: year.ID}, nil); err != nil {
+		t.Fatalf("same name in an academic year: %v", err)
+	}
+}
+
+func TestYearStudents(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	year := saveYear(t, s, "2025/2026")
+	other := saveYear(t, s, "2026/2027")
+
+	courseID, err := s.SaveCourse(ctx, &scheme.CourseCreation{Name: "Current", Number: 1, AcademicYearID: year.ID}, nil)
+	if err != nil {
+		t.Fatalf("SaveCourse: %v", err)
+	}
+	mustExec(t, s, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)", courseID, testStudentID)
+
+	tests := []struct {
+		name   string
+		yearID int64
+		want   int
+	}{
+		{name: "year of the course", yearID: year.ID, want: 1},
+		{name: "other year", yearID: other.ID, want: 0},
+		{name: "every year", yearID: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			students, err := s.YearStudents(ctx, tt.yearID)
+			if err != nil {
+				t.Fatalf("YearStudents: %v", err)
+			}
+			if len(students) != tt.want || (tt.want > 0 && students[0] != testStudentID) {
+				t.Fatalf("students = %v, want %d student(s)", students, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+// Get the roles of the user
+func (s *Storage) RoleList(ctx context.Context, userID int64) ([]scheme.UserRole, error) {
+	const fn = "storage.sqlite.RoleList"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT role, unit_id FROM user_roles WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	roles := make([]scheme.UserRole, 0)
+
+	for rows.Next() {
+		var role scheme.UserRole
+		var unitID sql.NullInt64
+		if err := rows.Scan(&role.Role, &unitID); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		role.UnitID = unitID.Int64
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (s *Storage) AddUserRole(ctx context.Context, userID int64, role scheme.UserRole) error {
+	const fn = "storage.sqlite.AddUserRole"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if _, err := s.User(ctx, userID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO user_roles (user_id, role, unit_id) VALUES (?, ?, ?)",
+		userID, role.Role, nullID(role.UnitID))
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := bumpRolesVersion(ctx, tx, userID, res); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RemoveUserRole(ctx context.Context, userID int64, role scheme.UserRole) error {
+	const fn = "storage.sqlite.RemoveUserRole"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = ? AND role = ? AND unit_id IS ?",
+		userID, role.Role, nullID(role.UnitID))
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return store.ErrRoleNotFound
+	}
+
+	if err := bumpRolesVersion(ctx, tx, userID, res); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// RolesVersion returns the version of the user's roles, it changes with every
+// role added or removed
+func (s *Storage) RolesVersion(ctx context.Context, userID int64) (int64, error) {
+	const fn = "storage.sqlite.RolesVersion"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var version int64
+
+	err := s.db.QueryRowContext(ctx, "SELECT roles_version FROM users WHERE id = ?", userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, store.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return version, nil
+}
+
+// bumpRolesVersion invalidates the cached roles of the user if res changed any row
+func bumpRolesVersion(ctx context.Context, tx *sql.Tx, userID int64, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET roles_version = roles_version + 1 WHERE id = ?", userID)
+	return err
+}
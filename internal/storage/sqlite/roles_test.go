@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+func TestAddUserRoleIsIdempotent(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	unitID := mustExec(t, s, "INSERT INTO org_units (name, kind) VALUES ('Institute', 'institute')")
+
+	add := []scheme.UserRole{
+		{Role: "teacher"},
+		{Role: "teacher"},
+		{Role: "admin", UnitID: unitID},
+		{Role: "admin", UnitID: unitID},
+		{Role: "admin"},
+	}
+	for _, role := range add {
+		if err := s.AddUserRole(ctx, testTeacherID, role); err != nil {
+			t.Fatalf("AddUserRole(%+v): %v", role, err)
+		}
+	}
+
+	roles, err := s.RoleList(ctx, testTeacherID)
+	if err != nil {
+		t.Fatalf("RoleList: %v", err)
+	}
+
+	want := []scheme.UserRole{{Role: "teacher"}, {Role: "admin", UnitID: unitID}, {Role: "admin"}}
+	if len(roles) != len(want) {
+		t.Fatalf("roles = %+v, want %+v", roles, want)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Fatalf("roles = %+v, want %+v", roles, want)
+		}
+	}
+}
+
+func TestRolesVersionChangesWithRoles(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	version := func() int64 {
+		t.Helper()
+		v, err := s.RolesVersion(ctx, testTeacherID)
+		if err != nil {
+			t.Fatalf("RolesVersion: %v", err)
+		}
+		return v
+	}
+
+	start := version()
+
+	if err := s.AddUserRole(ctx, testTeacherID, scheme.UserRole{Role: "admin"}); err != nil {
+		t.Fatalf("AddUserRole: %v", err)
+	}
+	added := version()
+	if added == start {
+		t.Fatalf("version %d did not change after adding a role", added)
+	}
+
+	// Adding a role the user holds changes nothing
+	if err := s.AddUserRole(ctx, testTeacherID, scheme.UserRole{Role: "admin"}); err != nil {
+		t.Fatalf("AddUserRole: %v", err)
+	}
+	if v := version(); v != added {
+		t.Fatalf("version = %d after adding a held role, want %d", v, added)
+	}
+
+	if err := s.RemoveUserRole(ctx, testTeacherID, scheme.UserRole{Role: "admin"}); err != nil {
+		t.Fatalf("RemoveUserRole: %v", err)
+	}
+	if v := version(); v == added {
+		t.Fatalf("version %d did not change after removing a role", v)
+	}
+
+	key, err := s.UserRoles(ctx, "tea@gmail.com")
+	if err != nil {
+		t.Fatalf("UserRoles: %v", err)
+	}
+	if key.RolesVersion != version() {
+		t.Fatalf("UserRoles version = %d, want %d", key.RolesVersion, version())
+	}
+}
@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+)
+
+const (
+	settingExamMinGapDays      = "exam_min_gap_days"
+	settingExamDurationMinutes = "exam_duration_minutes"
+
+	defaultExamDurationMinutes = 180
+)
+
+func (s *Storage) ExamRules(ctx context.Context) (scheme.ExamRules, error) {
+	const fn = "storage.sqlite.ExamRules"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rules := scheme.ExamRules{DurationMinutes: defaultExamDurationMinutes}
+
+	for key, dst := range map[string]*int{
+		settingExamMinGapDays:      &rules.MinGapDays,
+		settingExamDurationMinutes: &rules.DurationMinutes,
+	} {
+		value, ok, err := s.setting(ctx, key)
+		if err != nil {
+			return scheme.ExamRules{}, fmt.Errorf("%s:%w", fn, err)
+		}
+		if !ok {
+			continue
+		}
+
+		*dst, err = strconv.Atoi(value)
+		if err != nil {
+			return scheme.ExamRules{}, fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	return rules, nil
+}
+
+func (s *Storage) SetExamRules(ctx context.Context, rules scheme.ExamRules, audit *scheme.AuditEntry) error {
+	const fn = "storage.sqlite.SetExamRules"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := saveSetting(ctx, tx, settingExamMinGapDays, strconv.Itoa(rules.MinGapDays)); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := saveSetting(ctx, tx, settingExamDurationMinutes, strconv.Itoa(rules.DurationMinutes)); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
@@ -1,17 +1,28 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/arxonic/journal/internal/domain/models"
 	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/lib/logger/sl"
+	"github.com/arxonic/journal/internal/lib/metrics"
+	"github.com/arxonic/journal/internal/lib/tracing"
 	store "github.com/arxonic/journal/internal/storage"
 	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Storage calls taking longer are logged
+const slowCall = 250 * time.Millisecond
+
 type Storage struct {
 	db *sql.DB
 }
@@ -19,7 +30,14 @@ type Storage struct {
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite3", storagePath)
+	// Transactions take the write lock when they begin, so that the checks
+	// they read before writing cannot be passed by two of them at once
+	dsn := storagePath + "?_txlock=immediate"
+	if strings.Contains(storagePath, "?") {
+		dsn = storagePath + "&_txlock=immediate"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", op, err)
 	}
@@ -27,16 +45,67 @@ func New(storagePath string) (*Storage, error) {
 	return &Storage{db: db}, nil
 }
 
-func (s *Storage) User(userID int64) (scheme.User, error) {
+// observe times the storage call fn for the metrics and, within a traced
+// request or job, records it as a child span of ctx; done ends both. Slow
+// calls are logged with the logger of the request.
+// Polls of the background workers are not traced on their own.
+// A call runs several statements, so the span names the method rather than
+// a SQL operation. The statements take the returned ctx and are cancelled
+// with the request.
+func observe(ctx context.Context, fn string) (_ context.Context, done func()) {
+	start := time.Now()
+	method := fn[strings.LastIndexByte(fn, '.')+1:]
+
+	finish := func() {
+		d := time.Since(start)
+		metrics.ObserveStorage(fn, start)
+
+		if d < slowCall {
+			return
+		}
+		if log := sl.FromContext(ctx, nil); log != nil {
+			log.Warn("slow storage call", slog.String("fn", fn), slog.Duration("duration", d))
+		}
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, finish
+	}
+
+	ctx, span := tracing.Start(ctx, fn,
+		attribute.String("db.system", "sqlite"),
+		attribute.String("code.function", method),
+	)
+
+	return ctx, func() {
+		span.End()
+		finish()
+	}
+}
+
+// Close closes the database once the last query has finished
+func (s *Storage) Close() error {
+	const fn = "storage.sqlite.Close"
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) User(ctx context.Context, userID int64) (scheme.User, error) {
 	const fn = "storage.sqlite.User"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, last_name, first_name, patronymic FROM users WHERE id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, last_name, first_name, patronymic FROM users WHERE id = ?")
 	if err != nil {
 		return scheme.User{}, err
 	}
 
 	var user scheme.User
-	err = stmt.QueryRow(userID).Scan(&user.ID, &user.LastName, &user.FirstName, &user.Patronymic)
+	err = stmt.QueryRowContext(ctx, userID).Scan(&user.ID, &user.LastName, &user.FirstName, &user.Patronymic)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return scheme.User{}, store.ErrUserNotFound
@@ -47,16 +116,19 @@ func (s *Storage) User(userID int64) (scheme.User, error) {
 	return user, nil
 }
 
-func (s *Storage) UserRole(email string) (models.Key, error) {
-	const fn = "storage.sqlite.UserRole"
+func (s *Storage) UserRoles(ctx context.Context, email string) (models.Key, error) {
+	const fn = "storage.sqlite.UserRoles"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, email, role FROM users WHERE email = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, email, roles_version FROM users WHERE email = ?")
 	if err != nil {
 		return models.Key{}, err
 	}
+	defer stmt.Close()
 
 	var key models.Key
-	err = stmt.QueryRow(email).Scan(&key.ID, &key.Email, &key.Role)
+	err = stmt.QueryRowContext(ctx, email).Scan(&key.ID, &key.Email, &key.RolesVersion)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Key{}, store.ErrUserNotFound
@@ -64,19 +136,46 @@ func (s *Storage) UserRole(email string) (models.Key, error) {
 		return models.Key{}, fmt.Errorf("%s:%w", fn, err)
 	}
 
+	roles, err := s.RoleList(ctx, key.ID)
+	if err != nil {
+		return models.Key{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	key.Roles = make([]models.Role, 0, len(roles))
+	for _, r := range roles {
+		key.Roles = append(key.Roles, models.Role{Name: r.Role, Scope: r.UnitID})
+	}
+
 	return key, nil
 }
 
-func (s *Storage) SaveCourse(course *scheme.CourseCreation) (int64, error) {
+// SaveCourse creates the course with its assignments, the audit entry is
+// recorded for the new course
+func (s *Storage) SaveCourse(ctx context.Context, course *scheme.CourseCreation, audit *scheme.AuditEntry) (int64, error) {
 	const fn = "storage.sqlite.SaveCourse"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("INSERT INTO courses (num, name) VALUES (?, ?)")
+	archived, err := s.yearArchived(ctx, course.AcademicYearID)
 	if err != nil {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
-	defer stmt.Close()
+	if archived {
+		return 0, store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	if err := checkSubjectSemesters(ctx, tx, course.AcademicYearID, course.Subjects); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
 
-	res, err := stmt.Exec(course.Number, course.Name)
+	res, err := tx.ExecContext(ctx, "INSERT INTO courses (num, name, academic_year_id, unit_id) VALUES (?, ?, ?, ?)",
+		course.Number, course.Name, nullID(course.AcademicYearID), nullID(course.UnitID))
 	if err != nil {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -86,65 +185,150 @@ func (s *Storage) SaveCourse(course *scheme.CourseCreation) (int64, error) {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
 
-	stmt, err = s.db.Prepare("INSERT INTO assignments (course_id, discipline_id, teacher_id) VALUES (?, ?, ?)")
+	// Subjects without a scale are graded on the five-point one
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO assignments (course_id, discipline_id, teacher_id, semester_id, scale_id)
+		VALUES (?, ?, ?, ?, COALESCE(?, (SELECT id FROM grading_scales WHERE code = ?)))`)
 	if err != nil {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
+	defer stmt.Close()
 
 	for _, subject := range course.Subjects {
-		_, err = stmt.Exec(courseID, subject.DisciplineID, subject.TeacherID)
+		_, err = stmt.ExecContext(ctx, courseID, subject.DisciplineID, subject.TeacherID, nullID(subject.SemesterID), nullID(subject.ScaleID), scheme.ScaleFivePoint)
 		if err != nil {
 			return 0, fmt.Errorf("%s:%w", fn, err)
 		}
 	}
 
+	err = recordEvent(ctx, tx, scheme.TopicCourseCreated, scheme.CourseCreatedEvent{
+		CourseID:       courseID,
+		Name:           course.Name,
+		Number:         course.Number,
+		AcademicYearID: course.AcademicYearID,
+		UnitID:         course.UnitID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if audit != nil {
+		audit.EntityID = courseID
+	}
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
 	return courseID, nil
 }
 
-func (s *Storage) EnrollStudents(enrollments *scheme.Enrollments) error {
+func (s *Storage) EnrollStudents(ctx context.Context, enrollments *scheme.Enrollments, audit *scheme.AuditEntry) error {
 	const fn = "storage.sqlite.EnrollStudents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
 
-	stmt, err := s.db.Prepare("INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)")
 	if err != nil {
 		return fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
 	for _, enroll := range enrollments.Enrollments {
-		_, err = stmt.Exec(enroll.CourseID, enroll.StudentID)
+		archived, err := s.courseArchived(ctx, enroll.CourseID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+		if archived {
+			return store.ErrPeriodArchived
+		}
+
+		_, err = stmt.ExecContext(ctx, enroll.CourseID, enroll.StudentID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+
+		err = recordEvent(ctx, tx, scheme.TopicStudentEnrolled, scheme.EnrollmentEvent{CourseID: enroll.CourseID, StudentID: enroll.StudentID})
 		if err != nil {
 			return fmt.Errorf("%s:%w", fn, err)
 		}
 	}
 
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
 	return nil
 }
 
-func (s *Storage) RemoveStudents(enrollments *scheme.Enrollments) error {
+func (s *Storage) RemoveStudents(ctx context.Context, enrollments *scheme.Enrollments, audit *scheme.AuditEntry) error {
 	const fn = "storage.sqlite.RemoveStudents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
 
-	stmt, err := s.db.Prepare("DELETE FROM enrollments WHERE course_id = ? AND student_id = ?")
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM enrollments WHERE course_id = ? AND student_id = ?")
 	if err != nil {
 		return fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
 	for _, enroll := range enrollments.Enrollments {
-		_, err = stmt.Exec(enroll.CourseID, enroll.StudentID)
+		archived, err := s.courseArchived(ctx, enroll.CourseID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+		if archived {
+			return store.ErrPeriodArchived
+		}
+
+		_, err = stmt.ExecContext(ctx, enroll.CourseID, enroll.StudentID)
+		if err != nil {
+			return fmt.Errorf("%s:%w", fn, err)
+		}
+
+		err = recordEvent(ctx, tx, scheme.TopicStudentUnenrolled, scheme.EnrollmentEvent{CourseID: enroll.CourseID, StudentID: enroll.StudentID})
 		if err != nil {
 			return fmt.Errorf("%s:%w", fn, err)
 		}
 	}
 
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
 	return nil
 }
 
 // FIXME Simplify methods
 
-func (s *Storage) TeacherCourses(teacherID int64) (scheme.Courses, error) {
+func (s *Storage) TeacherCourses(ctx context.Context, teacherID int64, period scheme.Period) (scheme.Courses, error) {
 	const fn = "storage.sqlite.TeacherCourses"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	assignments, err := s.AssignmentsByFK(teacherID, "teacher_id")
+	assignments, err := s.AssignmentsByFK(ctx, teacherID, "teacher_id")
 	if err != nil {
 		return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -152,7 +336,19 @@ func (s *Storage) TeacherCourses(teacherID int64) (scheme.Courses, error) {
 	var courses scheme.Courses
 
 	for _, ass := range assignments.Assignments {
-		course, err := s.Course(ass.CourseID)
+		if period.SemesterID != 0 && ass.SemesterID != period.SemesterID {
+			continue
+		}
+
+		ok, err := s.courseInPeriod(ctx, ass.CourseID, period)
+		if err != nil {
+			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+		}
+		if !ok {
+			continue
+		}
+
+		course, err := s.Course(ctx, ass.CourseID)
 		if err != nil {
 			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
 		}
@@ -163,10 +359,12 @@ func (s *Storage) TeacherCourses(teacherID int64) (scheme.Courses, error) {
 	return courses, nil
 }
 
-func (s *Storage) StudentCourses(studentID int64) (scheme.Courses, error) {
+func (s *Storage) StudentCourses(ctx context.Context, studentID int64, period scheme.Period) (scheme.Courses, error) {
 	const fn = "storage.sqlite.StudentCourses"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	enrolls, err := s.EntollmentsByFK(studentID, "student_id")
+	enrolls, err := s.EntollmentsByFK(ctx, studentID, "student_id")
 	if err != nil {
 		return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -174,7 +372,15 @@ func (s *Storage) StudentCourses(studentID int64) (scheme.Courses, error) {
 	var courses scheme.Courses
 
 	for _, enroll := range enrolls.Enrollments {
-		course, err := s.Course(enroll.CourseID)
+		ok, err := s.courseInPeriod(ctx, enroll.CourseID, period)
+		if err != nil {
+			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+		}
+		if !ok {
+			continue
+		}
+
+		course, err := s.Course(ctx, enroll.CourseID)
 		if err != nil {
 			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
 		}
@@ -186,30 +392,38 @@ func (s *Storage) StudentCourses(studentID int64) (scheme.Courses, error) {
 }
 
 // Get the Course by ID
-func (s *Storage) Course(courseID int64) (scheme.Course, error) {
+func (s *Storage) Course(ctx context.Context, courseID int64) (scheme.Course, error) {
 	const fn = "storage.sqlite.Course"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, name, num FROM courses WHERE id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, name, num, academic_year_id, unit_id FROM courses WHERE id = ?")
 	if err != nil {
 		return scheme.Course{}, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
 	var course scheme.Course
+	var yearID, unitID sql.NullInt64
 
-	err = stmt.QueryRow(courseID).Scan(&course.ID, &course.Name, &course.Number)
+	err = stmt.QueryRowContext(ctx, courseID).Scan(&course.ID, &course.Name, &course.Number, &yearID, &unitID)
 	if err != nil {
 		return scheme.Course{}, fmt.Errorf("%s:%w", fn, err)
 	}
 
+	course.AcademicYearID = yearID.Int64
+	course.UnitID = unitID.Int64
+
 	return course, nil
 }
 
 // Get the Course by ID
-func (s *Storage) Discipline(disciplineID int64) (scheme.Discipline, error) {
+func (s *Storage) Discipline(ctx context.Context, disciplineID int64) (scheme.Discipline, error) {
 	const fn = "storage.sqlite.Discipline"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, name FROM disciplines WHERE id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, name FROM disciplines WHERE id = ?")
 	if err != nil {
 		return scheme.Discipline{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -217,7 +431,7 @@ func (s *Storage) Discipline(disciplineID int64) (scheme.Discipline, error) {
 
 	var disc scheme.Discipline
 
-	err = stmt.QueryRow(disciplineID).Scan(&disc.ID, &disc.Name)
+	err = stmt.QueryRowContext(ctx, disciplineID).Scan(&disc.ID, &disc.Name)
 	if err != nil {
 		return scheme.Discipline{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -225,10 +439,12 @@ func (s *Storage) Discipline(disciplineID int64) (scheme.Discipline, error) {
 	return disc, nil
 }
 
-func (s *Storage) DisciplineTeacher(courseID, disciplineID int64) ([]scheme.User, error) {
+func (s *Storage) DisciplineTeacher(ctx context.Context, courseID, disciplineID int64) ([]scheme.User, error) {
 	const fn = "storage.sqlite.DisciplineTeacher"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	teacherIDs, err := s.AssignmentsTeacher(courseID, disciplineID)
+	teacherIDs, err := s.AssignmentsTeacher(ctx, courseID, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -236,7 +452,7 @@ func (s *Storage) DisciplineTeacher(courseID, disciplineID int64) ([]scheme.User
 	users := make([]scheme.User, 0)
 
 	for _, id := range teacherIDs {
-		user, err := s.User(id)
+		user, err := s.User(ctx, id)
 		if err != nil {
 			return nil, fmt.Errorf("%s:%w", fn, err)
 		}
@@ -247,10 +463,12 @@ func (s *Storage) DisciplineTeacher(courseID, disciplineID int64) ([]scheme.User
 	return users, nil
 }
 
-func (s *Storage) CourseDisciplines(courseID int64) (scheme.Disciplines, error) {
+func (s *Storage) CourseDisciplines(ctx context.Context, courseID int64, period scheme.Period) (scheme.Disciplines, error) {
 	const fn = "storage.sqlite.CourseDisciplines"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	assignments, err := s.AssignmentsByFK(courseID, "course_id")
+	assignments, err := s.AssignmentsByFK(ctx, courseID, "course_id")
 	if err != nil {
 		return scheme.Disciplines{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -258,7 +476,11 @@ func (s *Storage) CourseDisciplines(courseID int64) (scheme.Disciplines, error)
 	var disciplines scheme.Disciplines
 
 	for _, ass := range assignments.Assignments {
-		disc, err := s.Discipline(ass.DisciplineID)
+		if period.SemesterID != 0 && ass.SemesterID != period.SemesterID {
+			continue
+		}
+
+		disc, err := s.Discipline(ctx, ass.DisciplineID)
 		if err != nil {
 			return scheme.Disciplines{}, fmt.Errorf("%s:%w", fn, err)
 		}
@@ -269,16 +491,18 @@ func (s *Storage) CourseDisciplines(courseID int64) (scheme.Disciplines, error)
 	return disciplines, nil
 }
 
-func (s *Storage) AssignmentID(courseID, disciplineID, teacherID int64) (int64, error) {
+func (s *Storage) AssignmentID(ctx context.Context, courseID, disciplineID, teacherID int64) (int64, error) {
 	const fn = "storage.sqlite.AssignmentID"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id FROM assignments WHERE course_id = ? AND discipline_id = ? AND teacher_id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id FROM assignments WHERE course_id = ? AND discipline_id = ? AND teacher_id = ?")
 	if err != nil {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(courseID, disciplineID, teacherID)
+	row := stmt.QueryRowContext(ctx, courseID, disciplineID, teacherID)
 
 	var id int64
 
@@ -289,16 +513,18 @@ func (s *Storage) AssignmentID(courseID, disciplineID, teacherID int64) (int64,
 	return id, nil
 }
 
-func (s *Storage) AssignmentsTeacher(courseID, disciplineID int64) ([]int64, error) {
+func (s *Storage) AssignmentsTeacher(ctx context.Context, courseID, disciplineID int64) ([]int64, error) {
 	const fn = "storage.sqlite.AssignmentsTeacher"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT teacher_id FROM assignments WHERE course_id = ? AND discipline_id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT teacher_id FROM assignments WHERE course_id = ? AND discipline_id = ?")
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(courseID, disciplineID)
+	rows, err := stmt.QueryContext(ctx, courseID, disciplineID)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -317,17 +543,19 @@ func (s *Storage) AssignmentsTeacher(courseID, disciplineID int64) ([]int64, err
 	return teacherIDs, nil
 }
 
-func (s *Storage) AssignmentsByFK(fk int64, fieldName string) (scheme.Assignments, error) {
+func (s *Storage) AssignmentsByFK(ctx context.Context, fk int64, fieldName string) (scheme.Assignments, error) {
 	const fn = "storage.sqlite.AssignmentsByFK"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	req := fmt.Sprintf("SELECT id, course_id, discipline_id, teacher_id FROM assignments WHERE %s = ?", fieldName)
-	stmt, err := s.db.Prepare(req)
+	req := fmt.Sprintf("SELECT id, course_id, discipline_id, teacher_id, semester_id, scale_id FROM assignments WHERE %s = ?", fieldName)
+	stmt, err := s.db.PrepareContext(ctx, req)
 	if err != nil {
 		return scheme.Assignments{}, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(fk)
+	rows, err := stmt.QueryContext(ctx, fk)
 	if err != nil {
 		return scheme.Assignments{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -336,27 +564,32 @@ func (s *Storage) AssignmentsByFK(fk int64, fieldName string) (scheme.Assignment
 
 	for rows.Next() {
 		var ass scheme.Assignment
-		if err := rows.Scan(&ass.ID, &ass.CourseID, &ass.DisciplineID, &ass.TeacherID); err != nil {
+		var semesterID sql.NullInt64
+		if err := rows.Scan(&ass.ID, &ass.CourseID, &ass.DisciplineID, &ass.TeacherID, &semesterID, &ass.ScaleID); err != nil {
 			return scheme.Assignments{}, fmt.Errorf("%s:%w", fn, err)
 		}
 
+		ass.SemesterID = semesterID.Int64
+
 		assignments.Assignments = append(assignments.Assignments, ass)
 	}
 
 	return assignments, nil
 }
 
-func (s *Storage) EntollmentsByFK(fk int64, fieldName string) (scheme.Enrollments, error) {
+func (s *Storage) EntollmentsByFK(ctx context.Context, fk int64, fieldName string) (scheme.Enrollments, error) {
 	const fn = "storage.sqlite.EntollmentsByFK"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
 	req := fmt.Sprintf("SELECT id, course_id, student_id FROM enrollments WHERE %s = ?", fieldName)
-	stmt, err := s.db.Prepare(req)
+	stmt, err := s.db.PrepareContext(ctx, req)
 	if err != nil {
 		return scheme.Enrollments{}, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(fk)
+	rows, err := stmt.QueryContext(ctx, fk)
 	if err != nil {
 		return scheme.Enrollments{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -375,33 +608,78 @@ func (s *Storage) EntollmentsByFK(fk int64, fieldName string) (scheme.Enrollment
 	return enrolls, nil
 }
 
-func (s *Storage) ExamSignUp(studentID, assignmentID int64, examDate time.Time) error {
+func (s *Storage) ExamSignUp(ctx context.Context, studentID, assignmentID int64, examDate time.Time, check func(booked []scheme.ExamEvent) []scheme.ExamConflict, audit *scheme.AuditEntry) ([]scheme.ExamConflict, error) {
 	const fn = "storage.sqlite.ExamSignUp"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("INSERT INTO exams (student_id, assignment_id, exam_date) VALUES (?, ?, ?)")
+	archived, err := s.assignmentArchived(ctx, assignmentID)
 	if err != nil {
-		return fmt.Errorf("%s:%w", fn, err)
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return nil, store.ErrPeriodArchived
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(studentID, assignmentID, examDate)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("%s:%w", fn, err)
+		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
+	defer tx.Rollback()
 
-	return nil
+	// The exams of the student are checked within the transaction, so that
+	// concurrent sign-ups cannot break the rules together
+	booked, err := examEvents(ctx, tx, studentExamsQuery, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	if conflicts := check(booked); len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO exams (student_id, assignment_id, exam_date) VALUES (?, ?, ?)", studentID, assignmentID, examDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	examID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	err = recordEvent(ctx, tx, scheme.TopicExamSignedUp, scheme.ExamSignedUpEvent{
+		ExamID:       examID,
+		StudentID:    studentID,
+		AssignmentID: assignmentID,
+		ExamDate:     examDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil, nil
 }
 
-func (s *Storage) ExamID(studentID, assignmentID int64, examDate time.Time) (int64, error) {
+func (s *Storage) ExamID(ctx context.Context, studentID, assignmentID int64, examDate time.Time) (int64, error) {
 	const fn = "storage.sqlite.ExamID"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id FROM exams WHERE student_id = ? AND assignment_id = ? AND exam_date = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id FROM exams WHERE student_id = ? AND assignment_id = ? AND exam_date = ?")
 	if err != nil {
 		return 0, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(studentID, assignmentID, examDate)
+	row := stmt.QueryRowContext(ctx, studentID, assignmentID, examDate)
 
 	var id int64
 
@@ -412,16 +690,18 @@ func (s *Storage) ExamID(studentID, assignmentID int64, examDate time.Time) (int
 	return id, nil
 }
 
-func (s *Storage) ExamsByStudentIDAndAssignmentID(studentID, assignmentID int64) ([]scheme.Exam, error) {
+func (s *Storage) ExamsByStudentIDAndAssignmentID(ctx context.Context, studentID, assignmentID int64) ([]scheme.Exam, error) {
 	const fn = "storage.sqlite.ExamsByStudentIDAndAssignmentID"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, exam_date FROM exams WHERE student_id = ? AND assignment_id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, exam_date FROM exams WHERE student_id = ? AND assignment_id = ?")
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(studentID, assignmentID)
+	rows, err := stmt.QueryContext(ctx, studentID, assignmentID)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -443,10 +723,13 @@ func (s *Storage) ExamsByStudentIDAndAssignmentID(studentID, assignmentID int64)
 	return exams, nil
 }
 
-func (s *Storage) GradeByExamID(examID int64) (scheme.Grade, error) {
+func (s *Storage) GradeByExamID(ctx context.Context, examID int64) (scheme.Grade, error) {
 	const fn = "storage.sqlite.GradeByExamID"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, teacher_id, grade, grade_date FROM grades WHERE exam_id = ?")
+	stmt, err := s.db.PrepareContext(ctx, `SELECT g.id, g.teacher_id, g.grade, g.scale_id, g.grade >= sc.pass_value, g.grade_date
+		FROM grades g JOIN grading_scales sc ON sc.id = g.scale_id WHERE g.exam_id = ?`)
 	if err != nil {
 		return scheme.Grade{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -454,7 +737,7 @@ func (s *Storage) GradeByExamID(examID int64) (scheme.Grade, error) {
 
 	var grade scheme.Grade
 
-	err = stmt.QueryRow(examID).Scan(&grade.ID, &grade.TeacherID, &grade.Grade, &grade.GradeDate)
+	err = stmt.QueryRowContext(ctx, examID).Scan(&grade.ID, &grade.TeacherID, &grade.Grade, &grade.ScaleID, &grade.Passed, &grade.GradeDate)
 	if err != nil {
 		return scheme.Grade{}, fmt.Errorf("%s:%w", fn, err)
 	}
@@ -464,38 +747,101 @@ func (s *Storage) GradeByExamID(examID int64) (scheme.Grade, error) {
 	return grade, nil
 }
 
-func (s *Storage) ExamGrade(examID, teacherID int64, grade int, examDate time.Time) error {
+// Grade the exam on the scale of its assignment
+func (s *Storage) ExamGrade(ctx context.Context, examID, teacherID int64, grade int, examDate time.Time, audit *scheme.AuditEntry) error {
 	const fn = "storage.sqlite.ExamGrade"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var assignmentID, studentID int64
 
-	stmt, err := s.db.Prepare("INSERT INTO grades (exam_id, teacher_id, grade, grade_date) VALUES (?, ?, ?, ?)")
+	err := s.db.QueryRowContext(ctx, "SELECT assignment_id, student_id FROM exams WHERE id = ?", examID).Scan(&assignmentID, &studentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	archived, err := s.assignmentArchived(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO grades (exam_id, teacher_id, grade, scale_id, grade_date)
+		SELECT ?, ?, ?, scale_id, ? FROM assignments WHERE id = ?`, examID, teacherID, grade, examDate, assignmentID)
 	if err != nil {
 		return fmt.Errorf("%s:%w", fn, err)
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(examID, teacherID, grade, examDate)
+	err = recordEvent(ctx, tx, scheme.TopicGradePosted, scheme.GradePostedEvent{
+		ExamID:       examID,
+		StudentID:    studentID,
+		AssignmentID: assignmentID,
+		TeacherID:    teacherID,
+		Grade:        grade,
+		GradeDate:    examDate,
+	})
 	if err != nil {
 		return fmt.Errorf("%s:%w", fn, err)
 	}
 
+	if err := appendAudit(ctx, tx, audit); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
 	return nil
 }
 
-func (s *Storage) Assignment(assignmentID int64) (scheme.Assignment, error) {
+// TeachesStudent reports whether the teacher has an assignment in a course the student is enrolled in
+func (s *Storage) TeachesStudent(ctx context.Context, teacherID, studentID int64) (bool, error) {
+	const fn = "storage.sqlite.TeachesStudent"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var teaches bool
+
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (
+			SELECT 1 FROM assignments a
+			JOIN enrollments e ON e.course_id = a.course_id
+			WHERE a.teacher_id = ? AND e.student_id = ?)`, teacherID, studentID).Scan(&teaches)
+	if err != nil {
+		return false, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return teaches, nil
+}
+
+func (s *Storage) Assignment(ctx context.Context, assignmentID int64) (scheme.Assignment, error) {
 	const fn = "storage.sqlite.Assignment"
+	ctx, done := observe(ctx, fn)
+	defer done()
 
-	stmt, err := s.db.Prepare("SELECT id, course_id, discipline_id, teacher_id FROM assignments WHERE id = ?")
+	stmt, err := s.db.PrepareContext(ctx, "SELECT id, course_id, discipline_id, teacher_id, semester_id, scale_id FROM assignments WHERE id = ?")
 	if err != nil {
 		return scheme.Assignment{}, fmt.Errorf("%s:%w", fn, err)
 	}
 	defer stmt.Close()
 
 	var assignment scheme.Assignment
+	var semesterID sql.NullInt64
 
-	err = stmt.QueryRow(assignmentID).Scan(&assignment.ID, &assignment.CourseID, &assignment.DisciplineID, &assignment.TeacherID)
+	err = stmt.QueryRowContext(ctx, assignmentID).Scan(&assignment.ID, &assignment.CourseID, &assignment.DisciplineID, &assignment.TeacherID, &semesterID, &assignment.ScaleID)
 	if err != nil {
 		return scheme.Assignment{}, fmt.Errorf("%s:%w", fn, err)
 	}
 
+	assignment.SemesterID = semesterID.Int64
+
 	return assignment, nil
 }
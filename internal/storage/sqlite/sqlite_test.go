@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// Users seeded by the migrations
+const (
+	testAdminID   int64 = 1
+	testTeacherID int64 = 2
+	testStudentID int64 = 3
+)
+
+// newTestStorage returns a storage on a fresh database migrated to SchemaVersion
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "journal.db")
+
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+path+"?x-migrations-table=migrations")
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	m.Close()
+
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	version, dirty, err := s.MigrationVersion(context.Background())
+	if err != nil || dirty || version != SchemaVersion {
+		t.Fatalf("migrated to %d (dirty %v, err %v), want %d", version, dirty, err, SchemaVersion)
+	}
+
+	return s
+}
+
+// mustExec runs a fixture statement
+func mustExec(t *testing.T, s *Storage, query string, args ...any) int64 {
+	t.Helper()
+
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func TestCallsStopWithTheContext(t *testing.T) {
+	s := newTestStorage(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.UserRoles(ctx, "adm@gmail.com"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("UserRoles with a cancelled context: %v, want context.Canceled", err)
+	}
+	if _, err := s.RolesVersion(ctx, testAdminID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RolesVersion with a cancelled context: %v, want context.Canceled", err)
+	}
+}
+
+func TestExamSignUpChecksWithinTheTransaction(t *testing.T) {
+	s := newTestStorage(t)
+
+	courseID := mustExec(t, s, "INSERT INTO courses (num, name) VALUES (1, 'CS-21')")
+	mustExec(t, s, "INSERT INTO enrollments (course_id, student_id) VALUES (?, ?)", courseID, testStudentID)
+
+	const requests = 8
+
+	assignments := make([]int64, requests)
+	for i := range assignments {
+		disciplineID := mustExec(t, s, "INSERT INTO disciplines (name) VALUES (?)", fmt.Sprintf("Discipline %d", i))
+		assignments[i] = mustExec(t, s, "INSERT INTO assignments (course_id, discipline_id, teacher_id) VALUES (?, ?, ?)", courseID, disciplineID, testTeacherID)
+	}
+
+	// One exam a day
+	date := time.Date(2025, time.January, 15, 10, 0, 0, 0, time.UTC)
+	check := func(booked []scheme.ExamEvent) []scheme.ExamConflict {
+		for _, e := range booked {
+			if e.ExamDate.Equal(date) {
+				return []scheme.ExamConflict{{Kind: scheme.ConflictExamOverlap, AssignmentID: e.AssignmentID}}
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		signed int
+	)
+	for _, assignmentID := range assignments {
+		wg.Add(1)
+		go func(assignmentID int64) {
+			defer wg.Done()
+
+			conflicts, err := s.ExamSignUp(context.Background(), testStudentID, assignmentID, date, check, nil)
+			if err != nil {
+				t.Errorf("ExamSignUp: %v", err)
+				return
+			}
+			if len(conflicts) == 0 {
+				mu.Lock()
+				signed++
+				mu.Unlock()
+			}
+		}(assignmentID)
+	}
+	wg.Wait()
+
+	if signed != 1 {
+		t.Fatalf("signed up for %d of %d exams on the same day, want 1", signed, requests)
+	}
+
+	booked, err := s.StudentExamEvents(context.Background(), testStudentID)
+	if err != nil {
+		t.Fatalf("StudentExamEvents: %v", err)
+	}
+	if len(booked) != 1 {
+		t.Fatalf("%d exams stored, want 1", len(booked))
+	}
+}
@@ -0,0 +1,311 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+const lessonColumns = `l.id, l.assignment_id, a.course_id, a.discipline_id, a.teacher_id, l.room_id,
+	l.weekday, l.start_time, l.end_time, l.week_parity`
+
+func (s *Storage) SaveRoom(ctx context.Context, room *scheme.Room) (int64, error) {
+	const fn = "storage.sqlite.SaveRoom"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO rooms (name, building, capacity) VALUES (?, ?, ?)", room.Name, room.Building, room.Capacity)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) Rooms(ctx context.Context) (scheme.Rooms, error) {
+	const fn = "storage.sqlite.Rooms"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, building, capacity FROM rooms ORDER BY name")
+	if err != nil {
+		return scheme.Rooms{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	rooms := scheme.Rooms{Rooms: make([]scheme.Room, 0)}
+
+	for rows.Next() {
+		var room scheme.Room
+		var building sql.NullString
+		if err := rows.Scan(&room.ID, &room.Name, &building, &room.Capacity); err != nil {
+			return scheme.Rooms{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		room.Building = building.String
+
+		rooms.Rooms = append(rooms.Rooms, room)
+	}
+
+	return rooms, rows.Err()
+}
+
+func (s *Storage) Room(ctx context.Context, roomID int64) (scheme.Room, error) {
+	const fn = "storage.sqlite.Room"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var room scheme.Room
+	var building sql.NullString
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, building, capacity FROM rooms WHERE id = ?", roomID).
+		Scan(&room.ID, &room.Name, &building, &room.Capacity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Room{}, store.ErrRoomNotFound
+		}
+		return scheme.Room{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	room.Building = building.String
+
+	return room, nil
+}
+
+// SaveLesson stores the lesson unless check finds conflicts with the lessons
+// of its weekday in period and the size of its course. Both are read within
+// the transaction of the insert, so that concurrent lessons cannot double-book.
+func (s *Storage) SaveLesson(ctx context.Context, lesson *scheme.Lesson, period scheme.Period, check func(existing []scheme.Lesson, courseSize int) []scheme.LessonConflict) (int64, []scheme.LessonConflict, error) {
+	const fn = "storage.sqlite.SaveLesson"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	archived, err := s.assignmentArchived(ctx, lesson.AssignmentID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return 0, nil, store.ErrPeriodArchived
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	existing, err := weekdayLessons(ctx, tx, lesson.Weekday, period)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	size, err := courseSize(ctx, tx, lesson.CourseID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if conflicts := check(existing, size); len(conflicts) > 0 {
+		return 0, conflicts, nil
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO lessons (assignment_id, room_id, weekday, start_time, end_time, week_parity) VALUES (?, ?, ?, ?, ?, ?)",
+		lesson.AssignmentID, lesson.RoomID, lesson.Weekday, lesson.StartTime, lesson.EndTime, lesson.WeekParity)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil, nil
+}
+
+func (s *Storage) Lesson(ctx context.Context, lessonID int64) (scheme.Lesson, error) {
+	const fn = "storage.sqlite.Lesson"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+lessonColumns+" FROM lessons l JOIN assignments a ON a.id = l.assignment_id WHERE l.id = ?", lessonID)
+
+	lesson, err := scanLesson(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Lesson{}, store.ErrLessonNotFound
+		}
+		return scheme.Lesson{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return lesson, nil
+}
+
+func (s *Storage) DeleteLesson(ctx context.Context, lessonID int64) error {
+	const fn = "storage.sqlite.DeleteLesson"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	lesson, err := s.Lesson(ctx, lessonID)
+	if err != nil {
+		return err
+	}
+
+	archived, err := s.assignmentArchived(ctx, lesson.AssignmentID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if archived {
+		return store.ErrPeriodArchived
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM lessons WHERE id = ?", lessonID); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the lessons held on the weekday in the period, used for conflict detection.
+// Lessons without a semester run through the whole year and match any semester.
+func (s *Storage) WeekdayLessons(ctx context.Context, weekday int, period scheme.Period) ([]scheme.Lesson, error) {
+	const fn = "storage.sqlite.WeekdayLessons"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	lessons, err := weekdayLessons(ctx, s.db, weekday, period)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return lessons, nil
+}
+
+func weekdayLessons(ctx context.Context, db querier, weekday int, period scheme.Period) ([]scheme.Lesson, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+lessonColumns+`
+		FROM lessons l
+		JOIN assignments a ON a.id = l.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		WHERE l.weekday = ?
+		AND (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		AND (? = 0 OR a.semester_id IS NULL OR a.semester_id = ?)
+		ORDER BY l.start_time`,
+		weekday,
+		period.AcademicYearID, period.AcademicYearID,
+		period.SemesterID, period.SemesterID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLessons(rows)
+}
+
+// Number of students enrolled in the course
+func (s *Storage) CourseSize(ctx context.Context, courseID int64) (int, error) {
+	const fn = "storage.sqlite.CourseSize"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	n, err := courseSize(ctx, s.db, courseID)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return n, nil
+}
+
+func courseSize(ctx context.Context, db querier, courseID int64) (int, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM enrollments WHERE course_id = ?", courseID).Scan(&n)
+	return n, err
+}
+
+func (s *Storage) TeacherTimetable(ctx context.Context, teacherID int64, period scheme.Period) (scheme.Timetable, error) {
+	const fn = "storage.sqlite.TeacherTimetable"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	timetable, err := s.timetable(ctx, "a.teacher_id = ?", teacherID, period)
+	if err != nil {
+		return scheme.Timetable{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return timetable, nil
+}
+
+func (s *Storage) StudentTimetable(ctx context.Context, studentID int64, period scheme.Period) (scheme.Timetable, error) {
+	const fn = "storage.sqlite.StudentTimetable"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	timetable, err := s.timetable(ctx, "a.course_id IN (SELECT course_id FROM enrollments WHERE student_id = ?)", studentID, period)
+	if err != nil {
+		return scheme.Timetable{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return timetable, nil
+}
+
+func (s *Storage) timetable(ctx context.Context, cond string, id int64, period scheme.Period) (scheme.Timetable, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+lessonColumns+`
+		FROM lessons l
+		JOIN assignments a ON a.id = l.assignment_id
+		JOIN courses c ON c.id = a.course_id
+		WHERE `+cond+`
+		AND (? = 0 OR c.academic_year_id = ? OR c.academic_year_id IS NULL)
+		AND (? = 0 OR a.semester_id = ?)
+		ORDER BY l.weekday, l.start_time`,
+		id,
+		period.AcademicYearID, period.AcademicYearID,
+		period.SemesterID, period.SemesterID,
+	)
+	if err != nil {
+		return scheme.Timetable{}, err
+	}
+
+	lessons, err := scanLessons(rows)
+	if err != nil {
+		return scheme.Timetable{}, err
+	}
+
+	return scheme.Timetable{Lessons: lessons}, nil
+}
+
+func scanLessons(rows *sql.Rows) ([]scheme.Lesson, error) {
+	defer rows.Close()
+
+	lessons := make([]scheme.Lesson, 0)
+
+	for rows.Next() {
+		lesson, err := scanLesson(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lessons = append(lessons, lesson)
+	}
+
+	return lessons, rows.Err()
+}
+
+func scanLesson(row rowScanner) (scheme.Lesson, error) {
+	var l scheme.Lesson
+
+	err := row.Scan(&l.ID, &l.AssignmentID, &l.CourseID, &l.DisciplineID, &l.TeacherID, &l.RoomID,
+		&l.Weekday, &l.StartTime, &l.EndTime, &l.WeekParity)
+
+	return l, err
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	"github.com/arxonic/journal/internal/services/timetable"
+)
+
+func TestSaveLessonChecksWithinTheTransaction(t *testing.T) {
+	s := newTestStorage(t)
+
+	courseID := mustExec(t, s, "INSERT INTO courses (num, name) VALUES (1, 'CS-21')")
+	disciplineID := mustExec(t, s, "INSERT INTO disciplines (name) VALUES ('Physics')")
+	assignmentID := mustExec(t, s, "INSERT INTO assignments (course_id, discipline_id, teacher_id) VALUES (?, ?, ?)", courseID, disciplineID, testTeacherID)
+	room := scheme.Room{Name: "101", Capacity: 30}
+	room.ID = mustExec(t, s, "INSERT INTO rooms (name, capacity) VALUES (?, ?)", room.Name, room.Capacity)
+
+	const requests = 8
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		saved int
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lesson := scheme.Lesson{
+				AssignmentID: assignmentID,
+				CourseID:     courseID,
+				DisciplineID: disciplineID,
+				TeacherID:    testTeacherID,
+				RoomID:       room.ID,
+				Weekday:      1,
+				StartTime:    "09:00",
+				EndTime:      "10:30",
+				WeekParity:   scheme.WeekEvery,
+			}
+			check := func(existing []scheme.Lesson, size int) []scheme.LessonConflict {
+				return timetable.Conflicts(lesson, existing, room, size)
+			}
+
+			id, conflicts, err := s.SaveLesson(context.Background(), &lesson, scheme.Period{}, check)
+			if err != nil {
+				t.Errorf("SaveLesson: %v", err)
+				return
+			}
+			if id != 0 {
+				mu.Lock()
+				saved++
+				mu.Unlock()
+			} else if len(conflicts) == 0 {
+				t.Error("SaveLesson saved nothing without conflicts")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if saved != 1 {
+		t.Fatalf("saved %d of %d lessons at the same time, want 1", saved, requests)
+	}
+
+	lessons, err := s.WeekdayLessons(context.Background(), 1, scheme.Period{})
+	if err != nil {
+		t.Fatalf("WeekdayLessons: %v", err)
+	}
+	if len(lessons) != 1 {
+		t.Fatalf("%d lessons stored, want 1", len(lessons))
+	}
+}
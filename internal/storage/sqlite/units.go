@@ -0,0 +1,298 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+// Allowed parent kind for every unit kind
+var unitParents = map[string]string{
+	scheme.UnitInstitute:  "",
+	scheme.UnitFaculty:    scheme.UnitInstitute,
+	scheme.UnitDepartment: scheme.UnitFaculty,
+}
+
+func (s *Storage) SaveUnit(ctx context.Context, unit *scheme.Unit) (int64, error) {
+	const fn = "storage.sqlite.SaveUnit"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	parentKind, ok := unitParents[unit.Kind]
+	if !ok {
+		return 0, store.ErrInvalidUnit
+	}
+
+	if unit.ParentID == 0 && parentKind != "" {
+		return 0, store.ErrInvalidUnit
+	}
+
+	if unit.ParentID != 0 {
+		parent, err := s.Unit(ctx, unit.ParentID)
+		if err != nil {
+			return 0, err
+		}
+		if parent.Kind != parentKind {
+			return 0, store.ErrInvalidUnit
+		}
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO org_units (parent_id, kind, name) VALUES (?, ?, ?)",
+		nullID(unit.ParentID), unit.Kind, unit.Name)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get the Unit by ID
+func (s *Storage) Unit(ctx context.Context, unitID int64) (scheme.Unit, error) {
+	const fn = "storage.sqlite.Unit"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var unit scheme.Unit
+	var parentID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, parent_id, kind, name FROM org_units WHERE id = ?", unitID).
+		Scan(&unit.ID, &parentID, &unit.Kind, &unit.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scheme.Unit{}, store.ErrUnitNotFound
+		}
+		return scheme.Unit{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	unit.ParentID = parentID.Int64
+
+	return unit, nil
+}
+
+// subtreeQuery selects the IDs of all units below and including the roots,
+// the whole tree if there are no roots
+func subtreeQuery(roots []int64) (string, []any) {
+	seed := "parent_id IS NULL"
+	args := make([]any, 0, len(roots))
+
+	if len(roots) > 0 {
+		seed = "id IN (?" + strings.Repeat(", ?", len(roots)-1) + ")"
+		for _, id := range roots {
+			args = append(args, id)
+		}
+	}
+
+	return `WITH RECURSIVE subtree(id) AS (
+			SELECT id FROM org_units WHERE ` + seed + `
+			UNION
+			SELECT u.id FROM org_units u JOIN subtree t ON u.parent_id = t.id
+		)`, args
+}
+
+// Get the units of the subtrees rooted at scopes, the whole tree if there are no scopes
+func (s *Storage) Units(ctx context.Context, scopes []int64) (scheme.Units, error) {
+	const fn = "storage.sqlite.Units"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	subtree, args := subtreeQuery(scopes)
+
+	rows, err := s.db.QueryContext(ctx, subtree+`
+		SELECT u.id, u.parent_id, u.kind, u.name FROM org_units u
+		JOIN subtree t ON t.id = u.id
+		ORDER BY u.id`, args...)
+	if err != nil {
+		return scheme.Units{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	units := scheme.Units{Units: make([]scheme.Unit, 0)}
+
+	for rows.Next() {
+		var unit scheme.Unit
+		var parentID sql.NullInt64
+		if err := rows.Scan(&unit.ID, &parentID, &unit.Kind, &unit.Name); err != nil {
+			return scheme.Units{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		unit.ParentID = parentID.Int64
+
+		units.Units = append(units.Units, unit)
+	}
+
+	return units, rows.Err()
+}
+
+// UnitPath returns the unit and all of its ancestors up to the root.
+// It is nil for the zero unit.
+func (s *Storage) UnitPath(ctx context.Context, unitID int64) ([]int64, error) {
+	const fn = "storage.sqlite.UnitPath"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if unitID == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `WITH RECURSIVE path(id, parent_id) AS (
+			SELECT id, parent_id FROM org_units WHERE id = ?
+			UNION ALL
+			SELECT u.id, u.parent_id FROM org_units u JOIN path p ON u.id = p.parent_id
+		)
+		SELECT id FROM path`, unitID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	var path []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		path = append(path, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if len(path) == 0 {
+		return nil, store.ErrUnitNotFound
+	}
+
+	return path, nil
+}
+
+// Tables whose rows belong to an org unit
+var unitMemberTables = map[string]bool{
+	"users":       true,
+	"disciplines": true,
+	"courses":     true,
+}
+
+// UnitOf returns the org unit of the row, zero if it is not assigned to any
+func (s *Storage) UnitOf(ctx context.Context, table string, id int64) (int64, error) {
+	const fn = "storage.sqlite.UnitOf"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if !unitMemberTables[table] {
+		return 0, fmt.Errorf("%s:unknown table %s", fn, table)
+	}
+
+	var unitID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT unit_id FROM %s WHERE id = ?", table), id).Scan(&unitID)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return unitID.Int64, nil
+}
+
+// Move users, disciplines and courses into the unit
+func (s *Storage) SaveUnitMembers(ctx context.Context, unitID int64, members *scheme.UnitMembers) error {
+	const fn = "storage.sqlite.SaveUnitMembers"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	if _, err := s.Unit(ctx, unitID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	for table, ids := range map[string][]int64{
+		"users":       members.UserIDs,
+		"disciplines": members.DisciplineIDs,
+		"courses":     members.CourseIDs,
+	} {
+		for _, id := range ids {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET unit_id = ? WHERE id = ?", table), unitID, id)
+			if err != nil {
+				return fmt.Errorf("%s:%w", fn, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the courses of the subtrees rooted at scopes, all courses if there are no scopes
+func (s *Storage) UnitCourses(ctx context.Context, scopes []int64, period scheme.Period) (scheme.Courses, error) {
+	const fn = "storage.sqlite.UnitCourses"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	query := "SELECT id FROM courses ORDER BY id"
+	var args []any
+
+	if len(scopes) > 0 {
+		var subtree string
+		subtree, args = subtreeQuery(scopes)
+		query = subtree + " SELECT id FROM courses WHERE unit_id IN (SELECT id FROM subtree) ORDER BY id"
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	var courseIDs []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		courseIDs = append(courseIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	var courses scheme.Courses
+
+	for _, id := range courseIDs {
+		ok, err := s.courseInPeriod(ctx, id, period)
+		if err != nil {
+			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+		}
+		if !ok {
+			continue
+		}
+
+		course, err := s.Course(ctx, id)
+		if err != nil {
+			return scheme.Courses{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		courses.Courses = append(courses.Courses, course)
+	}
+
+	return courses, nil
+}
@@ -0,0 +1,363 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/arxonic/journal/internal/domain/scheme"
+	store "github.com/arxonic/journal/internal/storage"
+)
+
+// Save the webhook together with the topics it is subscribed to
+func (s *Storage) SaveWebhook(ctx context.Context, hook *scheme.Webhook) (int64, error) {
+	const fn = "storage.sqlite.SaveWebhook"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO webhooks (url, secret, created_at) VALUES (?, ?, ?)", hook.URL, hook.Secret, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO webhook_topics (webhook_id, topic) VALUES (?, ?)")
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer stmt.Close()
+
+	for _, topic := range hook.Topics {
+		if _, err := stmt.ExecContext(ctx, id, topic); err != nil {
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return id, nil
+}
+
+// Get the active webhooks, without their secrets
+func (s *Storage) Webhooks(ctx context.Context) (scheme.Webhooks, error) {
+	const fn = "storage.sqlite.Webhooks"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, created_at FROM webhooks WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return scheme.Webhooks{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	hooks := scheme.Webhooks{Webhooks: make([]scheme.Webhook, 0)}
+
+	for rows.Next() {
+		hook := scheme.Webhook{Topics: make([]string, 0)}
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.CreatedAt); err != nil {
+			return scheme.Webhooks{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		hooks.Webhooks = append(hooks.Webhooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return scheme.Webhooks{}, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	for i, hook := range hooks.Webhooks {
+		topics, err := s.webhookTopics(ctx, hook.ID)
+		if err != nil {
+			return scheme.Webhooks{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		hooks.Webhooks[i].Topics = topics
+	}
+
+	return hooks, nil
+}
+
+func (s *Storage) webhookTopics(ctx context.Context, webhookID int64) ([]string, error) {
+	const fn = "storage.sqlite.webhookTopics"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT topic FROM webhook_topics WHERE webhook_id = ? ORDER BY topic", webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	topics := make([]string, 0)
+
+	for rows.Next() {
+		var topic string
+		if err := rows.Scan(&topic); err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		topics = append(topics, topic)
+	}
+
+	return topics, rows.Err()
+}
+
+// Delete the webhook. Its delivery log is kept, pending deliveries are given up.
+func (s *Storage) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	const fn = "storage.sqlite.DeleteWebhook"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE webhooks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), webhookID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return store.ErrWebhookNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE webhook_deliveries SET status = ?, last_error = ?, next_attempt_at = NULL WHERE webhook_id = ? AND status = ?",
+		scheme.DeliveryFailed, "webhook deleted", webhookID, scheme.DeliveryPending)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Get the latest deliveries of the webhook, newest first
+func (s *Storage) WebhookDeliveries(ctx context.Context, webhookID int64, limit int) (scheme.WebhookDeliveries, error) {
+	const fn = "storage.sqlite.WebhookDeliveries"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	var n int
+
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhooks WHERE id = ?", webhookID).Scan(&n); err != nil {
+		return scheme.WebhookDeliveries{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	if n == 0 {
+		return scheme.WebhookDeliveries{}, store.ErrWebhookNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT d.id, d.webhook_id, d.status, d.attempts, d.response_code, d.last_error, d.next_attempt_at, d.delivered_at,
+		e.id, e.topic, e.payload, e.created_at
+		FROM webhook_deliveries d
+		JOIN domain_events e ON e.id = d.event_id
+		WHERE d.webhook_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?`, webhookID, limit)
+	if err != nil {
+		return scheme.WebhookDeliveries{}, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	deliveries := scheme.WebhookDeliveries{Deliveries: make([]scheme.WebhookDelivery, 0)}
+
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return scheme.WebhookDeliveries{}, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		deliveries.Deliveries = append(deliveries.Deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func scanDelivery(rows *sql.Rows, extra ...any) (scheme.WebhookDelivery, error) {
+	var d scheme.WebhookDelivery
+	var code sql.NullInt64
+	var lastErr sql.NullString
+	var next, delivered sql.NullTime
+	var payload string
+
+	dest := []any{&d.ID, &d.WebhookID, &d.Status, &d.Attempts, &code, &lastErr, &next, &delivered,
+		&d.Event.ID, &d.Event.Topic, &payload, &d.Event.CreatedAt}
+
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return scheme.WebhookDelivery{}, err
+	}
+
+	d.ResponseCode = int(code.Int64)
+	d.LastError = lastErr.String
+	d.Event.Payload = json.RawMessage(payload)
+	if next.Valid {
+		d.NextAttemptAt = &next.Time
+	}
+	if delivered.Valid {
+		d.DeliveredAt = &delivered.Time
+	}
+
+	return d, nil
+}
+
+// recordEvent puts the domain event into the outbox within the transaction of the change
+func recordEvent(ctx context.Context, tx *sql.Tx, topic string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO domain_events (topic, payload, created_at) VALUES (?, ?, ?)", topic, string(data), time.Now())
+	return err
+}
+
+// Fan out up to limit undispatched domain events into deliveries of the
+// webhooks subscribed to them. Returns the number of events dispatched.
+func (s *Storage) DispatchEvents(ctx context.Context, limit int) (int, error) {
+	const fn = "storage.sqlite.DispatchEvents"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, topic FROM domain_events WHERE dispatched_at IS NULL ORDER BY id LIMIT ?", limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	var events []scheme.DomainEvent
+
+	for rows.Next() {
+		var e scheme.DomainEvent
+		if err := rows.Scan(&e.ID, &e.Topic); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	now := time.Now()
+
+	for _, e := range events {
+		_, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO webhook_deliveries (webhook_id, event_id, status, next_attempt_at)
+			SELECT w.id, ?, ?, ? FROM webhooks w
+			WHERE w.deleted_at IS NULL
+			AND (NOT EXISTS (SELECT 1 FROM webhook_topics t WHERE t.webhook_id = w.id)
+				OR EXISTS (SELECT 1 FROM webhook_topics t WHERE t.webhook_id = w.id AND t.topic = ?))`,
+			e.ID, scheme.DeliveryPending, now, e.Topic)
+		if err != nil {
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE domain_events SET dispatched_at = ? WHERE id = ?", now, e.ID); err != nil {
+			return 0, fmt.Errorf("%s:%w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return len(events), nil
+}
+
+// Get up to limit pending deliveries due to be sent at now
+func (s *Storage) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]scheme.PendingDelivery, error) {
+	const fn = "storage.sqlite.DueWebhookDeliveries"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT d.id, d.webhook_id, d.status, d.attempts, d.response_code, d.last_error, d.next_attempt_at, d.delivered_at,
+		e.id, e.topic, e.payload, e.created_at, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN domain_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?`, scheme.DeliveryPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", fn, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]scheme.PendingDelivery, 0)
+
+	for rows.Next() {
+		var p scheme.PendingDelivery
+
+		p.WebhookDelivery, err = scanDelivery(rows, &p.URL, &p.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%w", fn, err)
+		}
+
+		deliveries = append(deliveries, p)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *Storage) WebhookDelivered(ctx context.Context, deliveryID int64, code int, at time.Time) error {
+	const fn = "storage.sqlite.WebhookDelivered"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	_, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, response_code = ?, last_error = NULL, next_attempt_at = NULL, delivered_at = ?
+		WHERE id = ?`, scheme.DeliveryDelivered, code, at, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
+
+// Record a failed attempt. The delivery is retried at next, a zero next gives it up.
+func (s *Storage) WebhookDeliveryFailed(ctx context.Context, deliveryID int64, code int, sendErr string, next time.Time) error {
+	const fn = "storage.sqlite.WebhookDeliveryFailed"
+	ctx, done := observe(ctx, fn)
+	defer done()
+
+	status := scheme.DeliveryPending
+	var nextAt any = next
+	if next.IsZero() {
+		status = scheme.DeliveryFailed
+		nextAt = nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, response_code = ?, last_error = ?, next_attempt_at = ?
+		WHERE id = ?`, status, nullID(int64(code)), sendErr, nextAt, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", fn, err)
+	}
+
+	return nil
+}
@@ -5,6 +5,33 @@ import (
 )
 
 var (
-	ErrUserNotFound   = errors.New("user not found")
-	ErrCourseNotFound = errors.New("course not found")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrCourseNotFound  = errors.New("course not found")
+	ErrPeriodNotFound  = errors.New("period not found")
+	ErrPeriodArchived  = errors.New("period is archived")
+	ErrInvalidSemester = errors.New("semester is outside the academic year")
+	ErrUnitNotFound    = errors.New("unit not found")
+	ErrInvalidUnit     = errors.New("invalid unit parent")
+	ErrRoleNotFound    = errors.New("role not found")
+
+	ErrProgrammeNotFound  = errors.New("programme not found")
+	ErrCurriculumNotFound = errors.New("curriculum not found")
+
+	ErrScaleNotFound = errors.New("scale not found")
+
+	ErrComponentNotFound = errors.New("component not found")
+	ErrInvalidScore      = errors.New("invalid score")
+
+	ErrSessionNotFound  = errors.New("class session not found")
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrNotEnrolled      = errors.New("student is not enrolled in the course")
+
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrLessonNotFound = errors.New("lesson not found")
+
+	ErrTokenNotFound = errors.New("token not found")
+
+	ErrEventNotFound = errors.New("academic event not found")
+
+	ErrWebhookNotFound = errors.New("webhook not found")
 )
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_lessons_weekday;
+DROP TABLE IF EXISTS lessons;
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,23 @@
+-- Таблица Rooms
+CREATE TABLE IF NOT EXISTS rooms(
+    id              INTEGER PRIMARY KEY,
+    name            VARCHAR(50) NOT NULL UNIQUE,
+    building        VARCHAR(100),
+    capacity        INTEGER NOT NULL CHECK(capacity > 0)
+);
+
+-- Таблица Lessons (weekly timetable of an assignment)
+CREATE TABLE IF NOT EXISTS lessons(
+    id              INTEGER PRIMARY KEY,
+    assignment_id   INTEGER NOT NULL,
+    room_id         INTEGER NOT NULL,
+    weekday         INTEGER NOT NULL CHECK(weekday BETWEEN 1 AND 7),
+    start_time      TEXT NOT NULL,
+    end_time        TEXT NOT NULL,
+    week_parity     TEXT CHECK(week_parity IN ('every', 'odd', 'even')) NOT NULL DEFAULT 'every',
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id),
+    FOREIGN KEY (room_id) REFERENCES rooms(id),
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lessons_weekday ON lessons(weekday);
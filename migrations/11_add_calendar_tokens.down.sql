@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_calendar_tokens_user;
+DROP TABLE IF EXISTS calendar_tokens;
@@ -0,0 +1,11 @@
+-- Таблица Calendar tokens (secret links to the iCalendar feeds, only the hash is kept)
+CREATE TABLE IF NOT EXISTS calendar_tokens(
+    id              INTEGER PRIMARY KEY,
+    user_id         INTEGER NOT NULL,
+    token_hash      CHAR(64) NOT NULL UNIQUE,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at      DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_tokens_user ON calendar_tokens(user_id);
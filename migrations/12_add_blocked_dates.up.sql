@@ -0,0 +1,8 @@
+-- Таблица Blocked dates (holidays and other days without exams)
+CREATE TABLE IF NOT EXISTS blocked_dates(
+    id              INTEGER PRIMARY KEY,
+    date_from       DATE NOT NULL,
+    date_to         DATE NOT NULL,
+    reason          VARCHAR(255) NOT NULL,
+    CHECK (date_from <= date_to)
+);
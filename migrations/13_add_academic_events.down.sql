@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS blocked_dates(
+    id              INTEGER PRIMARY KEY,
+    date_from       DATE NOT NULL,
+    date_to         DATE NOT NULL,
+    reason          VARCHAR(255) NOT NULL,
+    CHECK (date_from <= date_to)
+);
+
+INSERT INTO blocked_dates (date_from, date_to, reason)
+SELECT date_from, date_to, name FROM academic_events WHERE kind = 'holiday';
+
+DROP INDEX IF EXISTS idx_academic_events_year;
+DROP TABLE IF EXISTS academic_events;
@@ -0,0 +1,22 @@
+-- Таблица Academic events (terms, exam sessions, holidays, registration windows, deadlines).
+-- Events without an academic year are not bound to a year and apply to all of them.
+CREATE TABLE IF NOT EXISTS academic_events(
+    id                  INTEGER PRIMARY KEY,
+    academic_year_id    INTEGER,
+    kind                TEXT CHECK(kind IN ('term', 'exam_session', 'holiday', 'registration', 'deadline')) NOT NULL,
+    name                VARCHAR(255) NOT NULL,
+    date_from           DATE NOT NULL,
+    date_to             DATE NOT NULL,
+    FOREIGN KEY (academic_year_id) REFERENCES academic_years(id),
+    CHECK (date_from <= date_to)
+);
+
+CREATE INDEX IF NOT EXISTS idx_academic_events_year ON academic_events(academic_year_id, kind);
+
+-- Blocked dates become holidays of the year they fall into
+INSERT INTO academic_events (academic_year_id, kind, name, date_from, date_to)
+SELECT (SELECT y.id FROM academic_years y WHERE b.date_from BETWEEN y.start_date AND y.end_date ORDER BY y.id LIMIT 1),
+       'holiday', b.reason, b.date_from, b.date_to
+FROM blocked_dates b;
+
+DROP TABLE IF EXISTS blocked_dates;
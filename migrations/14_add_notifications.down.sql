@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notification_outbox_due;
+DROP TABLE IF EXISTS notification_outbox;
+DROP TABLE IF EXISTS notification_optouts;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,33 @@
+-- Таблица Notification preferences (missing row means Russian and every notification enabled)
+CREATE TABLE IF NOT EXISTS notification_preferences(
+    user_id     INTEGER PRIMARY KEY,
+    language    TEXT CHECK(language IN ('ru', 'en')) NOT NULL DEFAULT 'ru',
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Таблица Notification opt-outs
+CREATE TABLE IF NOT EXISTS notification_optouts(
+    user_id     INTEGER NOT NULL,
+    kind        TEXT CHECK(kind IN ('exam_signup', 'exam_graded', 'enrolled', 'unenrolled')) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    PRIMARY KEY (user_id, kind)
+);
+
+-- Таблица Notification outbox (rendered emails, sent and retried in the background)
+CREATE TABLE IF NOT EXISTS notification_outbox(
+    id                  INTEGER PRIMARY KEY,
+    user_id             INTEGER NOT NULL,
+    kind                TEXT NOT NULL,
+    email               VARCHAR(100) NOT NULL,
+    subject             VARCHAR(255) NOT NULL,
+    body                TEXT NOT NULL,
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at     DATETIME NOT NULL,
+    last_error          TEXT,
+    created_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at             DATETIME,
+    failed_at           DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_due ON notification_outbox(sent_at, failed_at, next_attempt_at);
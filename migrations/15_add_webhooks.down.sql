@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_domain_events_dispatched;
+DROP TABLE IF EXISTS domain_events;
+DROP TABLE IF EXISTS webhook_topics;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,45 @@
+-- Таблица Webhooks (subscriptions of other campus systems to domain events)
+CREATE TABLE IF NOT EXISTS webhooks(
+    id          INTEGER PRIMARY KEY,
+    url         VARCHAR(2048) NOT NULL,
+    secret      VARCHAR(255) NOT NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at  DATETIME
+);
+
+-- Таблица Webhook topics (no rows means every topic)
+CREATE TABLE IF NOT EXISTS webhook_topics(
+    webhook_id  INTEGER NOT NULL,
+    topic       TEXT NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id),
+    PRIMARY KEY (webhook_id, topic)
+);
+
+-- Таблица Domain events (transactional outbox, written together with the change)
+CREATE TABLE IF NOT EXISTS domain_events(
+    id              INTEGER PRIMARY KEY,
+    topic           TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at   DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_dispatched ON domain_events(dispatched_at);
+
+-- Таблица Webhook deliveries (one per webhook and event, also the delivery log)
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+    id                  INTEGER PRIMARY KEY,
+    webhook_id          INTEGER NOT NULL,
+    event_id            INTEGER NOT NULL,
+    status              TEXT CHECK(status IN ('pending', 'delivered', 'failed')) NOT NULL DEFAULT 'pending',
+    attempts            INTEGER NOT NULL DEFAULT 0,
+    response_code       INTEGER,
+    last_error          TEXT,
+    next_attempt_at     DATETIME,
+    delivered_at        DATETIME,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id),
+    FOREIGN KEY (event_id) REFERENCES domain_events(id),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
@@ -0,0 +1,27 @@
+-- Таблица Audit log (append-only, every entry carries the hash of the previous one)
+CREATE TABLE IF NOT EXISTS audit_log(
+    id          INTEGER PRIMARY KEY,
+    at          DATETIME NOT NULL,
+    actor_id    INTEGER NOT NULL,
+    action      VARCHAR(100) NOT NULL,
+    entity      VARCHAR(100) NOT NULL,
+    entity_id   INTEGER,
+    before      TEXT,
+    after       TEXT,
+    request_id  VARCHAR(100),
+    prev_hash   CHAR(64) NOT NULL,
+    hash        CHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
@@ -0,0 +1,3 @@
+-- Course names are unique within an academic year, courses without a year
+-- included; NULLs are distinct in a UNIQUE constraint
+CREATE UNIQUE INDEX IF NOT EXISTS idx_courses_name_year ON courses (name, IFNULL(academic_year_id, 0));
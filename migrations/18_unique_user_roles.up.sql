@@ -0,0 +1,6 @@
+-- NULLs are distinct in UNIQUE (user_id, role, unit_id), so institute-wide
+-- roles were inserted again on every assignment
+DELETE FROM user_roles WHERE id NOT IN (
+    SELECT MIN(id) FROM user_roles GROUP BY user_id, role, IFNULL(unit_id, 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_roles_unique ON user_roles (user_id, role, IFNULL(unit_id, 0));
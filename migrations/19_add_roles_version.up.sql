@@ -0,0 +1,3 @@
+-- Bumped on every change of the user's roles, the role cookie carries the
+-- version it was issued for and is refreshed once it is behind
+ALTER TABLE users ADD COLUMN roles_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DELETE FROM scale_conversions WHERE from_scale_id = 5 AND to_scale_id = 4;
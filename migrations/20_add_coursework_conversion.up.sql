@@ -0,0 +1,3 @@
+-- Gradebook totals of coursework assignments convert through the 100-point rating like exams
+INSERT OR IGNORE INTO scale_conversions (from_scale_id, to_scale_id, from_min, from_max, to_value) VALUES
+    (5, 4, 0, 59, 2), (5, 4, 60, 74, 3), (5, 4, 75, 89, 4), (5, 4, 90, 100, 5);
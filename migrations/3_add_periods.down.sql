@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_assignments_semester;
+ALTER TABLE assignments DROP COLUMN semester_id;
+
+DROP INDEX IF EXISTS idx_courses_year;
+CREATE TABLE IF NOT EXISTS courses_old(
+    id      INTEGER PRIMARY KEY,
+    num     INTEGER NOT NULL,
+    name    VARCHAR(100) NOT NULL UNIQUE
+);
+INSERT OR IGNORE INTO courses_old (id, num, name) SELECT id, num, name FROM courses;
+DROP TABLE courses;
+ALTER TABLE courses_old RENAME TO courses;
+
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS semesters;
+DROP TABLE IF EXISTS academic_years;
@@ -0,0 +1,43 @@
+-- Таблица Academic years
+CREATE TABLE IF NOT EXISTS academic_years(
+    id          INTEGER PRIMARY KEY,
+    name        VARCHAR(20) NOT NULL UNIQUE,
+    start_date  DATE NOT NULL,
+    end_date    DATE NOT NULL,
+    archived    BOOLEAN NOT NULL DEFAULT 0
+);
+
+-- Таблица Semesters
+CREATE TABLE IF NOT EXISTS semesters(
+    id                  INTEGER PRIMARY KEY,
+    academic_year_id    INTEGER NOT NULL,
+    num                 INTEGER NOT NULL CHECK(num IN (1, 2)),
+    start_date          DATE NOT NULL,
+    end_date            DATE NOT NULL,
+    FOREIGN KEY (academic_year_id) REFERENCES academic_years(id),
+    UNIQUE (academic_year_id, num)
+);
+
+-- Таблица Settings
+CREATE TABLE IF NOT EXISTS settings(
+    key     VARCHAR(50) PRIMARY KEY,
+    value   TEXT NOT NULL
+);
+
+-- Courses belong to an academic year, so the same name may repeat every year
+CREATE TABLE IF NOT EXISTS courses_new(
+    id                  INTEGER PRIMARY KEY,
+    num                 INTEGER NOT NULL,
+    name                VARCHAR(100) NOT NULL,
+    academic_year_id    INTEGER,
+    FOREIGN KEY (academic_year_id) REFERENCES academic_years(id),
+    UNIQUE (name, academic_year_id)
+);
+INSERT INTO courses_new (id, num, name) SELECT id, num, name FROM courses;
+DROP TABLE courses;
+ALTER TABLE courses_new RENAME TO courses;
+CREATE INDEX IF NOT EXISTS idx_courses_year ON courses (academic_year_id);
+
+-- Assignments belong to a semester
+ALTER TABLE assignments ADD COLUMN semester_id INTEGER REFERENCES semesters(id);
+CREATE INDEX IF NOT EXISTS idx_assignments_semester ON assignments (semester_id);
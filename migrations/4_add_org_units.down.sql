@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN unit_id;
+ALTER TABLE disciplines DROP COLUMN unit_id;
+ALTER TABLE courses DROP COLUMN unit_id;
+
+DROP INDEX IF EXISTS idx_org_units_parent;
+DROP TABLE IF EXISTS org_units;
@@ -0,0 +1,16 @@
+-- Таблица Org units (institute -> faculty -> department)
+CREATE TABLE IF NOT EXISTS org_units(
+    id          INTEGER PRIMARY KEY,
+    parent_id   INTEGER,
+    kind        TEXT CHECK(kind IN ('institute', 'faculty', 'department')) NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    FOREIGN KEY (parent_id) REFERENCES org_units(id),
+    UNIQUE (parent_id, name)
+);
+CREATE INDEX IF NOT EXISTS idx_org_units_parent ON org_units (parent_id);
+
+-- Courses, disciplines and users belong to an org unit.
+-- For admins the unit is also the scope of their permissions, NULL means the whole institute.
+ALTER TABLE courses ADD COLUMN unit_id INTEGER REFERENCES org_units(id);
+ALTER TABLE disciplines ADD COLUMN unit_id INTEGER REFERENCES org_units(id);
+ALTER TABLE users ADD COLUMN unit_id INTEGER REFERENCES org_units(id);
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN role TEXT CHECK(role IN ('student', 'teacher', 'admin', 'unknown')) NOT NULL DEFAULT 'unknown';
+
+-- Keep the most privileged role of every user
+UPDATE users SET role = (
+    SELECT ur.role FROM user_roles ur WHERE ur.user_id = users.id
+    ORDER BY CASE ur.role WHEN 'admin' THEN 0 WHEN 'teacher' THEN 1 ELSE 2 END
+    LIMIT 1
+) WHERE EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = users.id);
+
+DROP INDEX IF EXISTS idx_user_roles_user;
+DROP TABLE IF EXISTS user_roles;
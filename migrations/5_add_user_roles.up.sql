@@ -0,0 +1,17 @@
+-- Таблица User roles
+-- A user may hold several roles, admin roles may be scoped to an org unit (NULL means the whole institute)
+CREATE TABLE IF NOT EXISTS user_roles(
+    id          INTEGER PRIMARY KEY,
+    user_id     INTEGER NOT NULL,
+    role        TEXT CHECK(role IN ('student', 'teacher', 'admin')) NOT NULL,
+    unit_id     INTEGER,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (unit_id) REFERENCES org_units(id),
+    UNIQUE (user_id, role, unit_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_roles_user ON user_roles (user_id);
+
+INSERT INTO user_roles (user_id, role, unit_id)
+SELECT id, role, CASE WHEN role = 'admin' THEN unit_id END FROM users WHERE role <> 'unknown';
+
+ALTER TABLE users DROP COLUMN role;
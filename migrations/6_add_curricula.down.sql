@@ -0,0 +1,5 @@
+ALTER TABLE students DROP COLUMN curriculum_id;
+
+DROP TABLE IF EXISTS curriculum_items;
+DROP TABLE IF EXISTS curricula;
+DROP TABLE IF EXISTS programmes;
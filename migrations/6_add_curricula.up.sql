@@ -0,0 +1,35 @@
+-- Таблица Programmes
+CREATE TABLE IF NOT EXISTS programmes(
+    id          INTEGER PRIMARY KEY,
+    code        VARCHAR(20) NOT NULL UNIQUE,
+    name        VARCHAR(100) NOT NULL,
+    unit_id     INTEGER,
+    FOREIGN KEY (unit_id) REFERENCES org_units(id)
+);
+
+-- Таблица Curricula
+CREATE TABLE IF NOT EXISTS curricula(
+    id                  INTEGER PRIMARY KEY,
+    programme_id        INTEGER NOT NULL,
+    name                VARCHAR(100) NOT NULL,
+    admission_year      INTEGER NOT NULL,
+    elective_credits    INTEGER NOT NULL DEFAULT 0 CHECK(elective_credits >= 0),
+    FOREIGN KEY (programme_id) REFERENCES programmes(id),
+    UNIQUE (programme_id, admission_year)
+);
+
+-- Таблица Curriculum items
+CREATE TABLE IF NOT EXISTS curriculum_items(
+    id              INTEGER PRIMARY KEY,
+    curriculum_id   INTEGER NOT NULL,
+    discipline_id   INTEGER NOT NULL,
+    semester        INTEGER NOT NULL CHECK(semester BETWEEN 1 AND 12),
+    kind            TEXT CHECK(kind IN ('required', 'elective')) NOT NULL,
+    credit_hours    INTEGER NOT NULL CHECK(credit_hours > 0),
+    assessment      TEXT CHECK(assessment IN ('exam', 'pass_fail', 'coursework')) NOT NULL,
+    FOREIGN KEY (curriculum_id) REFERENCES curricula(id),
+    FOREIGN KEY (discipline_id) REFERENCES disciplines(id),
+    UNIQUE (curriculum_id, discipline_id, semester)
+);
+
+ALTER TABLE students ADD COLUMN curriculum_id INTEGER REFERENCES curricula(id);
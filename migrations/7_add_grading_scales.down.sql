@@ -0,0 +1,21 @@
+DROP TRIGGER IF EXISTS grades_in_scale;
+
+-- Grades that do not fit the five-point scale are lost
+CREATE TABLE IF NOT EXISTS grades_old(
+    id          INTEGER PRIMARY KEY,
+    exam_id     INTEGER NOT NULL UNIQUE,
+    teacher_id  INTEGER NOT NULL,
+    grade       INTEGER NOT NULL CHECK(grade BETWEEN 1 AND 5),
+    grade_date  DATE,
+    FOREIGN KEY (exam_id) REFERENCES exams(id),
+    FOREIGN KEY (teacher_id) REFERENCES users(id)
+);
+INSERT INTO grades_old (id, exam_id, teacher_id, grade, grade_date)
+SELECT id, exam_id, teacher_id, grade, grade_date FROM grades WHERE grade BETWEEN 1 AND 5;
+DROP TABLE grades;
+ALTER TABLE grades_old RENAME TO grades;
+
+ALTER TABLE assignments DROP COLUMN scale_id;
+
+DROP TABLE IF EXISTS scale_conversions;
+DROP TABLE IF EXISTS grading_scales;
@@ -0,0 +1,68 @@
+-- Таблица Grading scales
+CREATE TABLE IF NOT EXISTS grading_scales(
+    id          INTEGER PRIMARY KEY,
+    code        VARCHAR(30) NOT NULL UNIQUE,
+    name        VARCHAR(100) NOT NULL,
+    min_value   INTEGER NOT NULL,
+    max_value   INTEGER NOT NULL,
+    pass_value  INTEGER NOT NULL,
+    CHECK (min_value <= pass_value AND pass_value <= max_value)
+);
+
+INSERT INTO grading_scales (id, code, name, min_value, max_value, pass_value) VALUES
+    (1, 'five_point', 'Экзамен', 1, 5, 3),
+    (2, 'pass_fail', 'Зачёт', 0, 1, 1),
+    (3, 'differentiated', 'Дифференцированный зачёт', 2, 5, 3),
+    (4, 'coursework', 'Защита курсовой работы', 2, 5, 3),
+    (5, 'rating_100', '100-балльный рейтинг', 0, 100, 60);
+
+-- Таблица Scale conversions
+-- Values from_min..from_max of one scale map to to_value of another
+CREATE TABLE IF NOT EXISTS scale_conversions(
+    id              INTEGER PRIMARY KEY,
+    from_scale_id   INTEGER NOT NULL,
+    to_scale_id     INTEGER NOT NULL,
+    from_min        INTEGER NOT NULL,
+    from_max        INTEGER NOT NULL,
+    to_value        INTEGER NOT NULL,
+    FOREIGN KEY (from_scale_id) REFERENCES grading_scales(id),
+    FOREIGN KEY (to_scale_id) REFERENCES grading_scales(id),
+    CHECK (from_min <= from_max),
+    UNIQUE (from_scale_id, to_scale_id, from_min)
+);
+
+INSERT INTO scale_conversions (from_scale_id, to_scale_id, from_min, from_max, to_value) VALUES
+    (5, 1, 0, 59, 2), (5, 1, 60, 74, 3), (5, 1, 75, 89, 4), (5, 1, 90, 100, 5),
+    (5, 3, 0, 59, 2), (5, 3, 60, 74, 3), (5, 3, 75, 89, 4), (5, 3, 90, 100, 5),
+    (5, 2, 0, 59, 0), (5, 2, 60, 100, 1),
+    (1, 2, 1, 2, 0), (1, 2, 3, 5, 1),
+    (3, 1, 2, 2, 2), (3, 1, 3, 3, 3), (3, 1, 4, 4, 4), (3, 1, 5, 5, 5),
+    (4, 1, 2, 2, 2), (4, 1, 3, 3, 3), (4, 1, 4, 4, 4), (4, 1, 5, 5, 5);
+
+-- Assignments are graded on a scale, five-point by default
+ALTER TABLE assignments ADD COLUMN scale_id INTEGER NOT NULL DEFAULT 1 REFERENCES grading_scales(id);
+
+-- Grades keep the scale they were given on, the range is checked by a trigger instead of CHECK
+CREATE TABLE IF NOT EXISTS grades_new(
+    id          INTEGER PRIMARY KEY,
+    exam_id     INTEGER NOT NULL UNIQUE,
+    teacher_id  INTEGER NOT NULL,
+    grade       INTEGER NOT NULL,
+    scale_id    INTEGER NOT NULL DEFAULT 1,
+    grade_date  DATE,
+    FOREIGN KEY (exam_id) REFERENCES exams(id),
+    FOREIGN KEY (teacher_id) REFERENCES users(id),
+    FOREIGN KEY (scale_id) REFERENCES grading_scales(id)
+);
+INSERT INTO grades_new (id, exam_id, teacher_id, grade, grade_date)
+SELECT id, exam_id, teacher_id, grade, grade_date FROM grades;
+DROP TABLE grades;
+ALTER TABLE grades_new RENAME TO grades;
+
+CREATE TRIGGER IF NOT EXISTS grades_in_scale BEFORE INSERT ON grades
+WHEN NOT EXISTS (
+    SELECT 1 FROM grading_scales WHERE id = NEW.scale_id AND NEW.grade BETWEEN min_value AND max_value
+)
+BEGIN
+    SELECT RAISE(ABORT, 'grade is out of scale');
+END;
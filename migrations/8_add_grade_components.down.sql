@@ -0,0 +1,4 @@
+ALTER TABLE assignments DROP COLUMN final_formula;
+
+DROP TABLE IF EXISTS component_scores;
+DROP TABLE IF EXISTS grade_components;
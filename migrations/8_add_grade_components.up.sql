@@ -0,0 +1,28 @@
+-- Таблица Grade components (labs, tests, homework of an assignment)
+CREATE TABLE IF NOT EXISTS grade_components(
+    id              INTEGER PRIMARY KEY,
+    assignment_id   INTEGER NOT NULL,
+    name            VARCHAR(100) NOT NULL,
+    weight          REAL NOT NULL CHECK(weight > 0),
+    max_score       REAL NOT NULL CHECK(max_score > 0),
+    due_date        DATE,
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id),
+    UNIQUE (assignment_id, name)
+);
+
+-- Таблица Component scores
+CREATE TABLE IF NOT EXISTS component_scores(
+    id              INTEGER PRIMARY KEY,
+    component_id    INTEGER NOT NULL,
+    student_id      INTEGER NOT NULL,
+    teacher_id      INTEGER NOT NULL,
+    score           REAL NOT NULL CHECK(score >= 0),
+    score_date      DATE,
+    FOREIGN KEY (component_id) REFERENCES grade_components(id),
+    FOREIGN KEY (student_id) REFERENCES users(id),
+    FOREIGN KEY (teacher_id) REFERENCES users(id),
+    UNIQUE (component_id, student_id)
+);
+
+-- Formula the suggested final grade is computed with
+ALTER TABLE assignments ADD COLUMN final_formula TEXT CHECK(final_formula IN ('weighted_mean', 'weighted_sum')) NOT NULL DEFAULT 'weighted_mean';
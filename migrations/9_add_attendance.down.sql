@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS absence_documents;
+DROP TABLE IF EXISTS attendance;
+DROP INDEX IF EXISTS idx_class_sessions_assignment;
+DROP TABLE IF EXISTS class_sessions;
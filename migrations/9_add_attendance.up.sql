@@ -0,0 +1,41 @@
+-- Таблица Class sessions (lectures, seminars, labs of an assignment)
+CREATE TABLE IF NOT EXISTS class_sessions(
+    id              INTEGER PRIMARY KEY,
+    assignment_id   INTEGER NOT NULL,
+    session_date    DATETIME NOT NULL,
+    topic           VARCHAR(255),
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_class_sessions_assignment ON class_sessions(assignment_id);
+
+-- Таблица Attendance
+CREATE TABLE IF NOT EXISTS attendance(
+    id              INTEGER PRIMARY KEY,
+    session_id      INTEGER NOT NULL,
+    student_id      INTEGER NOT NULL,
+    teacher_id      INTEGER NOT NULL,
+    status          TEXT CHECK(status IN ('present', 'absent', 'excused', 'late')) NOT NULL,
+    FOREIGN KEY (session_id) REFERENCES class_sessions(id),
+    FOREIGN KEY (student_id) REFERENCES users(id),
+    FOREIGN KEY (teacher_id) REFERENCES users(id),
+    UNIQUE (session_id, student_id)
+);
+
+-- Таблица Absence documents (medical certificates and the like)
+CREATE TABLE IF NOT EXISTS absence_documents(
+    id              INTEGER PRIMARY KEY,
+    student_id      INTEGER NOT NULL,
+    date_from       DATE NOT NULL,
+    date_to         DATE NOT NULL,
+    reason          VARCHAR(255),
+    file_name       VARCHAR(255) NOT NULL,
+    content_type    VARCHAR(100) NOT NULL,
+    content         BLOB NOT NULL,
+    status          TEXT CHECK(status IN ('pending', 'approved', 'rejected')) NOT NULL DEFAULT 'pending',
+    reviewer_id     INTEGER,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (student_id) REFERENCES users(id),
+    FOREIGN KEY (reviewer_id) REFERENCES users(id),
+    CHECK (date_from <= date_to)
+);